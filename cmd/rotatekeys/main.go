@@ -0,0 +1,85 @@
+// Command rotatekeys re-encrypts stored message content and variables, of live
+// and archived messages, with the active encryption key. Run it after changing
+// ENCRYPTION_ACTIVE_KEY_ID, keeping the old key configured until it finishes,
+// or after turning encryption on to encrypt existing plain text. It is safe
+// to interrupt and run again.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/encryption"
+	"github.com/kubilayrn/ChronoGo/internal/logging"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", 500, "messages re-encrypted per transaction")
+	flag.Parse()
+
+	slog.SetDefault(logging.NewLoggerFromEnv())
+
+	if *batchSize <= 0 {
+		fatal("Invalid batch size", errors.New("-batch-size must be positive"))
+	}
+
+	if err := encryption.LoadKeysFromEnv(); err != nil {
+		fatal("Failed to load encryption keys", err)
+	}
+	if encryption.Keys == nil {
+		fatal("Nothing to rotate to", errors.New("ENCRYPTION_KEYS and ENCRYPTION_KEYS_FILE are not set"))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := database.Connect(ctx, database.LoadConfigFromEnv()); err != nil {
+		fatal("Failed to connect to database", err)
+	}
+	defer database.Close()
+
+	slog.Info("Re-encrypting message content", "key_id", encryption.Keys.ActiveKeyID())
+
+	messageRepo := repository.NewMessageRepository()
+	// Live messages go first: retention only moves rows into the archive, so
+	// rows archived meanwhile were either rotated already or are still ahead.
+	total := rotate(ctx, "messages", messageRepo.ReencryptContent, *batchSize)
+	total += rotate(ctx, "messages_archive", messageRepo.ReencryptArchivedContent, *batchSize)
+
+	slog.Info("Message content re-encrypted", "key_id", encryption.Keys.ActiveKeyID(), "rewritten", total)
+}
+
+// rotate runs reencrypt over table batch by batch and returns how many rows
+// it rewrote. It exits on failure.
+func rotate(ctx context.Context, table string, reencrypt func(ctx context.Context, afterID, limit int) (int, int, error), batchSize int) int {
+	total := 0
+	afterID := 0
+	for {
+		lastID, rewritten, err := reencrypt(ctx, afterID, batchSize)
+		if err != nil {
+			// Batches already committed stay rotated; a rerun carries on.
+			slog.Error("Failed to re-encrypt message content", "table", table, "after_id", afterID, "rewritten", total, "error", err)
+			os.Exit(1)
+		}
+		if lastID == 0 {
+			return total
+		}
+
+		total += rewritten
+		afterID = lastID
+		slog.Debug("Re-encrypted batch", "table", table, "last_id", lastID, "rewritten", rewritten)
+	}
+}
+
+// fatal logs msg with err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -2,23 +2,39 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/kubilayrn/ChronoGo/internal/auth"
 	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/dedup"
+	"github.com/kubilayrn/ChronoGo/internal/encryption"
 	"github.com/kubilayrn/ChronoGo/internal/handler"
+	"github.com/kubilayrn/ChronoGo/internal/logging"
+	"github.com/kubilayrn/ChronoGo/internal/metrics"
+	"github.com/kubilayrn/ChronoGo/internal/model"
 	"github.com/kubilayrn/ChronoGo/internal/queue"
+	"github.com/kubilayrn/ChronoGo/internal/ratelimit"
+	"github.com/kubilayrn/ChronoGo/internal/recipient"
 	"github.com/kubilayrn/ChronoGo/internal/redis"
 	"github.com/kubilayrn/ChronoGo/internal/repository"
+	"github.com/kubilayrn/ChronoGo/internal/retention"
 	"github.com/kubilayrn/ChronoGo/internal/sender"
+	"github.com/kubilayrn/ChronoGo/internal/templating"
+	"github.com/kubilayrn/ChronoGo/internal/tracing"
 
 	_ "github.com/kubilayrn/ChronoGo/docs"
 )
@@ -38,36 +54,134 @@ import (
 // @BasePath  /api
 
 // @schemes http https
+
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 OIDC access token, as "Bearer <token>"
 func main() {
+	slog.SetDefault(logging.NewLoggerFromEnv())
 
 	ctx := context.Background()
+	tracerProvider, err := tracing.InitFromEnv(ctx)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+	if tracerProvider == nil {
+		slog.Info("OTEL_EXPORTER_OTLP_ENDPOINT is not set, tracing is disabled")
+	} else {
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tracerProvider.Shutdown(ctx); err != nil {
+				slog.Warn("Failed to flush traces", "error", err)
+			}
+		}()
+	}
+
+	if err := encryption.LoadKeysFromEnv(); err != nil {
+		fatal("Failed to load encryption keys", err)
+	}
+	if encryption.Keys == nil {
+		slog.Info("ENCRYPTION_KEYS and ENCRYPTION_KEYS_FILE are not set, message content is stored unencrypted")
+	} else {
+		slog.Info("Message content is encrypted at rest", "key_id", encryption.Keys.ActiveKeyID())
+	}
+
 	dbConfig := database.LoadConfigFromEnv()
 	if err := database.Connect(ctx, dbConfig); err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", err)
 	}
 	defer database.Close()
-	log.Println("Database connection established")
+	slog.Info("Database connection established")
 
 	redisConfig := redis.LoadConfigFromEnv()
 	if err := redis.Connect(ctx, redisConfig); err != nil {
-		log.Printf("Failed to connect to Redis (continuing without cache): %v", err)
+		slog.Warn("Failed to connect to Redis (continuing without cache)", "error", err)
 	} else {
 		defer redis.Close()
-		log.Println("Redis connection established")
+		slog.Info("Redis connection established")
 	}
 
 	messageRepo := repository.NewMessageRepository()
+	attemptRepo := repository.NewAttemptRepository()
+	outboxRepo := repository.NewOutboxRepository()
+	templateRepo := repository.NewTemplateRepository()
+	suppressionRepo := repository.NewSuppressionRepository()
+	campaignRepo := repository.NewCampaignRepository()
+	contactRepo := repository.NewContactRepository()
+	apiKeyRepo := repository.NewAPIKeyRepository()
+	tenantRepo := repository.NewTenantRepository()
+	auditRepo := repository.NewAuditRepository()
+	retentionRepo := repository.NewRetentionRepository()
+	renderer := templating.NewRenderer(templateRepo)
 	webhookSender := sender.NewWebhookSender()
-	scheduler := queue.NewScheduler(messageRepo, webhookSender)
-	h := handler.NewHandler(messageRepo, scheduler)
+	deduplicator, err := dedup.NewDeduplicatorFromEnv()
+	if err != nil {
+		fatal("Invalid deduplication settings", err)
+	}
+	scheduler := queue.NewScheduler(messageRepo, outboxRepo, suppressionRepo, tenantRepo, renderer, deduplicator, webhookSender)
+	metrics.RegisterBacklog(outboxRepo.GetBacklog)
+	recipients := recipient.NewRegistryFromEnv()
+	limiter := ratelimit.NewLimiterFromEnv(tenantRepo)
+	tokens, err := auth.NewTokenValidatorFromEnv(ctx)
+	if err != nil {
+		fatal("Failed to load JWKS", err)
+	}
+	if tokens == nil {
+		slog.Info("JWT_JWKS_URL and JWT_JWKS_FILE are not set, bearer tokens are disabled")
+	}
+	purger, err := retention.NewPurgerFromEnv(retentionRepo)
+	if err != nil {
+		fatal("Invalid retention policy", err)
+	}
+	h := handler.NewHandler(
+		messageRepo, attemptRepo, templateRepo, suppressionRepo, campaignRepo, contactRepo, apiKeyRepo, tenantRepo, auditRepo,
+		retentionRepo, renderer, recipients, deduplicator, scheduler,
+	)
 
 	if err := scheduler.Start(); err != nil {
-		log.Printf("Failed to start scheduler automatically: %v", err)
+		slog.Warn("Failed to start scheduler automatically", "error", err)
 	} else {
-		log.Println("Scheduler started automatically on deployment")
+		slog.Info("Scheduler started automatically on deployment")
 	}
 
-	r := gin.Default()
+	purger.Start()
+	defer purger.Stop()
+	if !purger.Enabled() {
+		slog.Info("RETENTION_DAYS and RETENTION_DAYS_BY_STATUS are not set, messages are kept forever")
+	}
+
+	requestTimeout := 30 * time.Second
+	if value := os.Getenv("REQUEST_TIMEOUT_SECONDS"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err == nil && seconds <= 0 {
+			err = fmt.Errorf("must be positive, got %d", seconds)
+		}
+		if err != nil {
+			fatal("Invalid REQUEST_TIMEOUT_SECONDS", err)
+		}
+		requestTimeout = time.Duration(seconds) * time.Second
+	}
+
+	r := gin.New()
+	r.Use(
+		handler.RequestID(), handler.Tracing(), handler.Metrics(), handler.AccessLog(), gin.Recovery(),
+		handler.Timeout(requestTimeout),
+	)
+	// ClientIP, used for per-IP rate limits, only believes X-Forwarded-For
+	// from trusted proxies.
+	var trustedProxies []string
+	if value := os.Getenv("TRUSTED_PROXIES"); value != "" {
+		trustedProxies = strings.Split(value, ",")
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		fatal("Invalid TRUSTED_PROXIES", err)
+	}
 
 	r.GET("/health", func(c *gin.Context) {
 		ctx := context.Background()
@@ -86,12 +200,74 @@ func main() {
 		})
 	})
 
-	api := r.Group("/api")
+	api := r.Group("/api",
+		handler.RateLimitByIP(limiter),
+		h.Authenticate(os.Getenv("ADMIN_API_KEY"), tokens),
+		handler.RateLimitByCaller(limiter),
+	)
+	{
+		read := api.Group("", handler.RequireScope(model.ScopeMessagesRead))
+		read.GET("/messages/sent", h.ListSentMessages)
+		read.GET("/messages/:id/attempts", h.ListMessageAttempts)
+		read.GET("/messages/:id/parts", h.ListMessageParts)
+		read.GET("/templates", h.ListTemplates)
+		read.GET("/templates/:id", h.GetTemplate)
+		read.GET("/templates/:id/variants", h.ListTemplateVariants)
+		read.GET("/suppressions", h.ListSuppressions)
+		read.GET("/suppressions/:recipient", h.GetSuppression)
+		read.GET("/contacts", h.ListContacts)
+		read.GET("/contacts/:id", h.GetContact)
+		read.GET("/campaigns", h.ListCampaigns)
+		read.GET("/campaigns/:id", h.GetCampaign)
+
+		write := api.Group("", handler.RequireScope(model.ScopeMessagesWrite))
+		write.POST("/messages", handler.Idempotency(requestTimeout), h.CreateMessage)
+		write.POST("/messages/batch", handler.Idempotency(requestTimeout), h.CreateMessages)
+		write.POST("/templates", h.CreateTemplate)
+		write.PUT("/templates/:id", h.UpdateTemplate)
+		write.DELETE("/templates/:id", h.DeleteTemplate)
+		write.PUT("/templates/:id/variants/:locale", h.PutTemplateVariant)
+		write.DELETE("/templates/:id/variants/:locale", h.DeleteTemplateVariant)
+		write.POST("/suppressions", h.CreateSuppression)
+		write.POST("/suppressions/import", h.ImportSuppressions)
+		write.DELETE("/suppressions/:recipient", h.DeleteSuppression)
+		write.POST("/contacts", h.CreateContact)
+		write.POST("/contacts/import", h.ImportContacts)
+		write.DELETE("/contacts/:id", h.DeleteContact)
+		write.POST("/campaigns", handler.Idempotency(requestTimeout), h.CreateCampaign)
+		write.POST("/campaigns/:id/pause", h.PauseCampaign)
+		write.POST("/campaigns/:id/resume", h.ResumeCampaign)
+		write.POST("/campaigns/:id/cancel", h.CancelCampaign)
+
+		admin := api.Group("", handler.RequireScope(model.ScopeSchedulerAdmin))
+		admin.POST("/scheduler/toggle", h.ToggleScheduler)
+		admin.GET("/retention/runs", h.ListRetentionRuns)
+
+		keys := api.Group("", handler.RequireScope(model.ScopeKeysAdmin))
+		keys.POST("/keys", h.CreateAPIKey)
+		keys.GET("/keys", h.ListAPIKeys)
+		keys.DELETE("/keys/:id", h.RevokeAPIKey)
+
+		tenants := api.Group("", handler.RequireScope(model.ScopeTenantsAdmin))
+		tenants.PUT("/tenants/:id", h.PutTenant)
+		tenants.GET("/tenants", h.ListTenants)
+		tenants.GET("/tenants/:id", h.GetTenant)
+
+		audit := api.Group("", handler.RequireScope(model.ScopeAuditRead))
+		audit.GET("/audit", h.ListAuditEntries)
+	}
+
+	callbacks := r.Group("/api/callbacks")
 	{
-		api.GET("/messages/sent", h.ListSentMessages)
-		api.POST("/scheduler/toggle", h.ToggleScheduler)
+		if callbackAuthKey := os.Getenv("CALLBACK_AUTH_KEY"); callbackAuthKey != "" {
+			callbacks.POST("/delivery-receipts", handler.CallbackAuth(callbackAuthKey), h.ReceiveDeliveryReceipt)
+		} else {
+			slog.Info("CALLBACK_AUTH_KEY is not set, delivery receipt callbacks for the shared webhook are disabled")
+		}
+		callbacks.POST("/:tenant/delivery-receipts", h.TenantCallbackAuth(), h.ReceiveTenantDeliveryReceipt)
 	}
 
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	srv := &http.Server{
@@ -101,22 +277,28 @@ func main() {
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			fatal("Failed to start server", err)
 		}
 	}()
 
-	log.Println("Server started on :8080")
+	slog.Info("Server started on :8080")
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server...")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		fatal("Server forced to shutdown", err)
 	}
 
-	log.Println("Server exited")
+	slog.Info("Server exited")
+}
+
+// fatal logs msg with err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// apiKeyPrefix marks ChronoGo keys so they are easy to spot in leaked
+	// configuration.
+	apiKeyPrefix = "cg_"
+	apiKeyBytes  = 24
+	// displayPrefixLength is how much of a key is stored in clear text.
+	displayPrefixLength = len(apiKeyPrefix) + 8
+)
+
+// GenerateAPIKey returns a new random key, the part of it that may be shown
+// in listings, and the hash to store.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	key = apiKeyPrefix + hex.EncodeToString(buf)
+	return key, key[:displayPrefixLength], HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes key for storage and lookup. Keys are random, so a fast
+// hash without salt is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKey is a signing key of a test identity provider.
+type testKey struct {
+	kid    string
+	signer crypto.Signer
+	method jwt.SigningMethod
+}
+
+func newRSAKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	return testKey{kid: kid, signer: key, method: jwt.SigningMethodRS256}
+}
+
+func newECKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	return testKey{kid: kid, signer: key, method: jwt.SigningMethodES256}
+}
+
+func (k testKey) jwk() map[string]string {
+	encode := func(n *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(n.Bytes())
+	}
+	switch pub := k.signer.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kid": k.kid, "kty": "RSA", "use": "sig", "n": encode(pub.N), "e": encode(big.NewInt(int64(pub.E)))}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kid": k.kid, "kty": "EC", "crv": "P-256", "x": encode(pub.X), "y": encode(pub.Y)}
+	}
+	panic("unsupported key type")
+}
+
+// sign returns a token for claims signed with k and naming its kid.
+func (k testKey) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+	signed, err := token.SignedString(k.signer)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func jwksJSON(t *testing.T, keys ...testKey) []byte {
+	t.Helper()
+	set := struct {
+		Keys []map[string]string `json:"keys"`
+	}{Keys: []map[string]string{}}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, key.jwk())
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("failed to marshal JWKS: %v", err)
+	}
+	return data
+}
+
+// writeJWKS writes a key set with keys to a file and returns its path.
+func writeJWKS(t *testing.T, keys ...testKey) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwksJSON(t, keys...), 0o600); err != nil {
+		t.Fatalf("failed to write JWKS: %v", err)
+	}
+	return path
+}
+
+// jwksServer serves a key set that tests can replace or make fail, and
+// counts how often it was fetched.
+type jwksServer struct {
+	mu      sync.Mutex
+	data    []byte
+	status  int
+	fetches int
+}
+
+func newJWKSServer(t *testing.T, data []byte) (*jwksServer, string) {
+	t.Helper()
+	s := &jwksServer{data: data, status: http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		w.WriteHeader(s.status)
+		w.Write(s.data)
+	}))
+	t.Cleanup(server.Close)
+	return s, server.URL
+}
+
+func (s *jwksServer) set(data []byte, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data, s.status = data, status
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+// age makes the keys of ks look fetched d ago.
+func (ks *KeySet) age(d time.Duration) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.fetchedAt = time.Now().Add(-d)
+}
+
+func TestKeySetFromFile(t *testing.T) {
+	ctx := context.Background()
+	rsaKey := newRSAKey(t, "rsa")
+	ecKey := newECKey(t, "ec")
+
+	ks, err := NewKeySet(ctx, writeJWKS(t, rsaKey, ecKey))
+	if err != nil {
+		t.Fatalf("NewKeySet returned error: %v", err)
+	}
+
+	for _, want := range []testKey{rsaKey, ecKey} {
+		key, err := ks.Key(ctx, want.kid)
+		if err != nil {
+			t.Fatalf("Key(%q) returned error: %v", want.kid, err)
+		}
+		if !want.signer.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(key) {
+			t.Errorf("Key(%q) returned another key", want.kid)
+		}
+	}
+
+	if _, err := ks.Key(ctx, "other"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key(other) error = %v, want ErrUnknownKey", err)
+	}
+	if _, err := ks.Key(ctx, ""); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key(\"\") with two keys error = %v, want ErrUnknownKey", err)
+	}
+
+	single, err := NewKeySet(ctx, writeJWKS(t, rsaKey))
+	if err != nil {
+		t.Fatalf("NewKeySet returned error: %v", err)
+	}
+	if _, err := single.Key(ctx, ""); err != nil {
+		t.Errorf("Key(\"\") with one key returned error: %v", err)
+	}
+}
+
+func TestNewKeySetErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "invalid JSON", data: `{"keys":`},
+		{name: "no keys", data: `{"keys":[]}`},
+		{name: "only symmetric keys", data: `{"keys":[{"kid":"a","kty":"oct","k":"c2VjcmV0"}]}`},
+		{name: "only encryption keys", data: `{"keys":[{"kid":"a","kty":"RSA","use":"enc","n":"AQAB","e":"AQAB"}]}`},
+		{name: "invalid modulus", data: `{"keys":[{"kid":"a","kty":"RSA","n":"!!","e":"AQAB"}]}`},
+		{name: "unsupported curve", data: `{"keys":[{"kid":"a","kty":"EC","crv":"P-192","x":"AQ","y":"AQ"}]}`},
+		{name: "point not on curve", data: `{"keys":[{"kid":"a","kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jwks.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+				t.Fatalf("failed to write JWKS: %v", err)
+			}
+			if _, err := NewKeySet(context.Background(), path); err == nil {
+				t.Error("NewKeySet returned no error")
+			}
+		})
+	}
+
+	if _, err := NewKeySet(context.Background(), filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("NewKeySet of a missing file returned no error")
+	}
+}
+
+func TestKeySetRefetchesUnknownKey(t *testing.T) {
+	ctx := context.Background()
+	first := newRSAKey(t, "first")
+	second := newRSAKey(t, "second")
+
+	server, url := newJWKSServer(t, jwksJSON(t, first))
+	ks, err := NewKeySet(ctx, url)
+	if err != nil {
+		t.Fatalf("NewKeySet returned error: %v", err)
+	}
+	server.set(jwksJSON(t, first, second), http.StatusOK)
+
+	// Right after a fetch an unknown key id does not fetch again.
+	if _, err := ks.Key(ctx, "second"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key(second) error = %v, want ErrUnknownKey", err)
+	}
+	if got := server.fetchCount(); got != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", got)
+	}
+
+	ks.age(jwksMinRefreshInterval + time.Second)
+	if _, err := ks.Key(ctx, "second"); err != nil {
+		t.Errorf("Key(second) after the key set changed returned error: %v", err)
+	}
+	if got := server.fetchCount(); got != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", got)
+	}
+
+	// Made-up key ids cannot make it fetch on every token.
+	for range 3 {
+		if _, err := ks.Key(ctx, "made-up"); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("Key(made-up) error = %v, want ErrUnknownKey", err)
+		}
+	}
+	if got := server.fetchCount(); got != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", got)
+	}
+
+	// Known keys are used without fetching until they are stale.
+	if _, err := ks.Key(ctx, "first"); err != nil {
+		t.Errorf("Key(first) returned error: %v", err)
+	}
+	if got := server.fetchCount(); got != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", got)
+	}
+
+	// Stale keys are fetched again, dropping keys removed by the provider.
+	server.set(jwksJSON(t, second), http.StatusOK)
+	ks.age(jwksRefreshInterval + time.Second)
+	if _, err := ks.Key(ctx, "first"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key(first) after it was removed error = %v, want ErrUnknownKey", err)
+	}
+	if got := server.fetchCount(); got != 3 {
+		t.Errorf("JWKS fetched %d times, want 3", got)
+	}
+}
+
+func TestKeySetKeepsKeysWhenRefreshFails(t *testing.T) {
+	ctx := context.Background()
+	key := newRSAKey(t, "key")
+
+	server, url := newJWKSServer(t, jwksJSON(t, key))
+	ks, err := NewKeySet(ctx, url)
+	if err != nil {
+		t.Fatalf("NewKeySet returned error: %v", err)
+	}
+	server.set([]byte("unavailable"), http.StatusServiceUnavailable)
+
+	ks.age(jwksRefreshInterval + time.Second)
+	if _, err := ks.Key(ctx, "key"); err != nil {
+		t.Errorf("Key(key) while the provider fails returned error: %v", err)
+	}
+	if got := server.fetchCount(); got != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", got)
+	}
+
+	ks.age(jwksMinRefreshInterval + time.Second)
+	if _, err := ks.Key(ctx, "other"); err == nil || errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key(other) while the provider fails error = %v, want the fetch error", err)
+	}
+
+	server.set(jwksJSON(t, key), http.StatusOK)
+	ks.age(jwksMinRefreshInterval + time.Second)
+	if _, err := ks.Key(ctx, "key"); err != nil {
+		t.Errorf("Key(key) after the provider recovered returned error: %v", err)
+	}
+}
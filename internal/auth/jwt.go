@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/joho/godotenv"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+// defaultScopesClaim is the OAuth 2.0 claim listing granted scopes. "scp" is
+// also read, as some providers use it instead.
+const defaultScopesClaim = "scope"
+
+// defaultTenantClaim names the tenant of the caller.
+const defaultTenantClaim = "tenant_id"
+
+// clockSkew is how far token timestamps may be off from our clock.
+const clockSkew = time.Minute
+
+// TokenValidator verifies bearer tokens issued by an OIDC provider and maps
+// their claims to scopes.
+type TokenValidator struct {
+	keys        *KeySet
+	issuer      string
+	audience    string
+	scopesClaim string
+	tenantClaim string
+	// defaultTenant is the tenant of tokens without a tenant claim. When empty
+	// such tokens are rejected. It is never the operator tenant.
+	defaultTenant string
+	// scopeMap maps claim values, e.g. provider roles, to scopes. Values that
+	// are already scope names need no entry.
+	scopeMap map[string][]model.Scope
+}
+
+// NewTokenValidatorFromEnv reads JWT_JWKS_URL or JWT_JWKS_FILE, JWT_ISSUER,
+// JWT_AUDIENCE, JWT_SCOPES_CLAIM, JWT_SCOPE_MAP, JWT_TENANT_CLAIM and
+// JWT_DEFAULT_TENANT. It returns nil when no key set is configured, which
+// disables bearer tokens.
+func NewTokenValidatorFromEnv(ctx context.Context) (*TokenValidator, error) {
+	_ = godotenv.Load()
+
+	source := os.Getenv("JWT_JWKS_URL")
+	if source == "" {
+		source = os.Getenv("JWT_JWKS_FILE")
+	}
+	if source == "" {
+		return nil, nil
+	}
+
+	keys, err := NewKeySet(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	scopeMap, err := parseScopeMap(os.Getenv("JWT_SCOPE_MAP"))
+	if err != nil {
+		return nil, err
+	}
+
+	scopesClaim := os.Getenv("JWT_SCOPES_CLAIM")
+	if scopesClaim == "" {
+		scopesClaim = defaultScopesClaim
+	}
+
+	tenantClaim := os.Getenv("JWT_TENANT_CLAIM")
+	if tenantClaim == "" {
+		tenantClaim = defaultTenantClaim
+	}
+
+	// Tokens from a shared identity provider may lack the claim, and must not
+	// become operators because of it.
+	defaultTenant := os.Getenv("JWT_DEFAULT_TENANT")
+	if defaultTenant != "" {
+		if err := model.ValidateTenantID(defaultTenant); err != nil {
+			return nil, fmt.Errorf("invalid JWT_DEFAULT_TENANT: %w", err)
+		}
+		if defaultTenant == model.DefaultTenant {
+			return nil, fmt.Errorf("JWT_DEFAULT_TENANT cannot be the %q tenant, operator tokens must carry the %s claim", model.DefaultTenant, tenantClaim)
+		}
+	}
+
+	issuer := os.Getenv("JWT_ISSUER")
+	audience := os.Getenv("JWT_AUDIENCE")
+	if issuer == "" {
+		slog.Warn("JWT_ISSUER is not set, tokens from any issuer signed by the JWKS are accepted")
+	}
+	if audience == "" {
+		slog.Warn("JWT_AUDIENCE is not set, tokens for any audience signed by the JWKS are accepted")
+	}
+
+	return &TokenValidator{
+		keys:          keys,
+		issuer:        issuer,
+		audience:      audience,
+		scopesClaim:   scopesClaim,
+		tenantClaim:   tenantClaim,
+		defaultTenant: defaultTenant,
+		scopeMap:      scopeMap,
+	}, nil
+}
+
+// parseScopeMap parses "value=scope,value=scope". A value may be listed more
+// than once to grant several scopes.
+func parseScopeMap(s string) (map[string][]model.Scope, error) {
+	scopeMap := make(map[string][]model.Scope)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		value, name, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid JWT_SCOPE_MAP entry %q", entry)
+		}
+		scope, err := model.ParseScope(strings.TrimSpace(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_SCOPE_MAP entry %q: %w", entry, err)
+		}
+		value = strings.TrimSpace(value)
+		scopeMap[value] = append(scopeMap[value], scope)
+	}
+	return scopeMap, nil
+}
+
+// Validate verifies the token's signature, expiry, issuer and audience and
+// returns the caller it identifies. Tokens without a tenant claim are
+// rejected unless a default tenant is configured for them.
+func (v *TokenValidator) Validate(ctx context.Context, token string) (*Principal, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	}
+	if v.issuer != "" {
+		options = append(options, jwt.WithIssuer(v.issuer))
+	}
+	if v.audience != "" {
+		options = append(options, jwt.WithAudience(v.audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	tenantID := v.defaultTenant
+	if raw, ok := claims[v.tenantClaim]; ok {
+		tenant, _ := raw.(string)
+		if err := model.ValidateTenantID(tenant); err != nil {
+			return nil, err
+		}
+		tenantID = tenant
+	}
+	if tenantID == "" {
+		return nil, fmt.Errorf("token has no %s claim", v.tenantClaim)
+	}
+
+	return &Principal{
+		Subject:  "jwt:" + subject,
+		TenantID: tenantID,
+		Scopes:   v.scopes(claims),
+	}, nil
+}
+
+// scopes maps the values of the scopes claim to scopes. The claim may be a
+// space separated string or an array of strings.
+func (v *TokenValidator) scopes(claims jwt.MapClaims) []model.Scope {
+	raw, ok := claims[v.scopesClaim]
+	if !ok && v.scopesClaim == defaultScopesClaim {
+		raw = claims["scp"]
+	}
+
+	var values []string
+	switch raw := raw.(type) {
+	case string:
+		values = strings.Fields(raw)
+	case []interface{}:
+		for _, value := range raw {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	granted := make(map[model.Scope]bool)
+	var scopes []model.Scope
+	grant := func(scope model.Scope) {
+		if !granted[scope] {
+			granted[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	for _, value := range values {
+		if scope, err := model.ParseScope(value); err == nil {
+			grant(scope)
+		}
+		for _, scope := range v.scopeMap[value] {
+			grant(scope)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,374 @@
+package auth
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+const (
+	testIssuer   = "https://id.example.com"
+	testAudience = "chronogo"
+)
+
+func newTestValidator(t *testing.T, keys ...testKey) *TokenValidator {
+	t.Helper()
+	ks, err := NewKeySet(context.Background(), writeJWKS(t, keys...))
+	if err != nil {
+		t.Fatalf("NewKeySet returned error: %v", err)
+	}
+	return &TokenValidator{
+		keys:        ks,
+		issuer:      testIssuer,
+		audience:    testAudience,
+		scopesClaim: defaultScopesClaim,
+		tenantClaim: defaultTenantClaim,
+		scopeMap:    map[string][]model.Scope{},
+	}
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":       "user-1",
+		"iss":       testIssuer,
+		"aud":       testAudience,
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"tenant_id": "acme",
+		"scope":     "messages:read messages:write",
+	}
+}
+
+func TestValidate(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	ecKey := newECKey(t, "ec")
+	stranger := newRSAKey(t, "rsa")
+
+	tests := []struct {
+		name      string
+		claims    func(jwt.MapClaims)
+		sign      func(t *testing.T, claims jwt.MapClaims) string
+		validator func(*TokenValidator)
+		wantErr   bool
+		tenant    string
+		scopes    []model.Scope
+	}{
+		{
+			name:   "valid",
+			tenant: "acme",
+			scopes: []model.Scope{model.ScopeMessagesRead, model.ScopeMessagesWrite},
+		},
+		{
+			name:   "valid EC key",
+			sign:   ecKey.sign,
+			tenant: "acme",
+			scopes: []model.Scope{model.ScopeMessagesRead, model.ScopeMessagesWrite},
+		},
+		{
+			name:    "expired",
+			claims:  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-2 * clockSkew).Unix() },
+			wantErr: true,
+		},
+		{
+			name:   "expired within clock skew",
+			claims: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-clockSkew / 2).Unix() },
+			tenant: "acme",
+			scopes: []model.Scope{model.ScopeMessagesRead, model.ScopeMessagesWrite},
+		},
+		{
+			name:    "not yet valid",
+			claims:  func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(2 * clockSkew).Unix() },
+			wantErr: true,
+		},
+		{
+			name:    "no expiry",
+			claims:  func(c jwt.MapClaims) { delete(c, "exp") },
+			wantErr: true,
+		},
+		{
+			name:    "wrong issuer",
+			claims:  func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+			wantErr: true,
+		},
+		{
+			name:    "wrong audience",
+			claims:  func(c jwt.MapClaims) { c["aud"] = "another-service" },
+			wantErr: true,
+		},
+		{
+			name:   "audience among several",
+			claims: func(c jwt.MapClaims) { c["aud"] = []string{"another-service", testAudience} },
+			tenant: "acme",
+			scopes: []model.Scope{model.ScopeMessagesRead, model.ScopeMessagesWrite},
+		},
+		{
+			name:      "any issuer and audience when not configured",
+			claims:    func(c jwt.MapClaims) { c["iss"], c["aud"] = "https://other.example.com", "another-service" },
+			validator: func(v *TokenValidator) { v.issuer, v.audience = "", "" },
+			tenant:    "acme",
+			scopes:    []model.Scope{model.ScopeMessagesRead, model.ScopeMessagesWrite},
+		},
+		{
+			name: "alg none",
+			sign: func(t *testing.T, claims jwt.MapClaims) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+				token.Header["kid"] = "rsa"
+				signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+				if err != nil {
+					t.Fatalf("failed to sign token: %v", err)
+				}
+				return signed
+			},
+			wantErr: true,
+		},
+		{
+			name: "HS256",
+			sign: func(t *testing.T, claims jwt.MapClaims) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+				token.Header["kid"] = "rsa"
+				signed, err := token.SignedString([]byte("a shared secret anyone could guess"))
+				if err != nil {
+					t.Fatalf("failed to sign token: %v", err)
+				}
+				return signed
+			},
+			wantErr: true,
+		},
+		{
+			name:    "unknown kid",
+			sign:    newRSAKey(t, "unknown").sign,
+			wantErr: true,
+		},
+		{
+			name:    "known kid signed by another key",
+			sign:    stranger.sign,
+			wantErr: true,
+		},
+		{
+			name:    "no subject",
+			claims:  func(c jwt.MapClaims) { delete(c, "sub") },
+			wantErr: true,
+		},
+		{
+			name:    "no tenant claim",
+			claims:  func(c jwt.MapClaims) { delete(c, "tenant_id") },
+			wantErr: true,
+		},
+		{
+			name:      "no tenant claim with a default tenant",
+			claims:    func(c jwt.MapClaims) { delete(c, "tenant_id") },
+			validator: func(v *TokenValidator) { v.defaultTenant = "shared" },
+			tenant:    "shared",
+			scopes:    []model.Scope{model.ScopeMessagesRead, model.ScopeMessagesWrite},
+		},
+		{
+			name:      "tenant claim replaces the default tenant",
+			validator: func(v *TokenValidator) { v.defaultTenant = "shared" },
+			tenant:    "acme",
+			scopes:    []model.Scope{model.ScopeMessagesRead, model.ScopeMessagesWrite},
+		},
+		{
+			name:    "invalid tenant",
+			claims:  func(c jwt.MapClaims) { c["tenant_id"] = "Acme Inc" },
+			wantErr: true,
+		},
+		{
+			name:    "empty tenant",
+			claims:  func(c jwt.MapClaims) { c["tenant_id"] = "" },
+			wantErr: true,
+		},
+		{
+			name:    "tenant is not a string",
+			claims:  func(c jwt.MapClaims) { c["tenant_id"] = 42 },
+			wantErr: true,
+		},
+		{
+			name: "custom tenant claim",
+			claims: func(c jwt.MapClaims) {
+				delete(c, "tenant_id")
+				c["org"] = "globex"
+			},
+			validator: func(v *TokenValidator) { v.tenantClaim = "org" },
+			tenant:    "globex",
+			scopes:    []model.Scope{model.ScopeMessagesRead, model.ScopeMessagesWrite},
+		},
+		{
+			name:   "unknown scopes are ignored",
+			claims: func(c jwt.MapClaims) { c["scope"] = "openid messages:read profile" },
+			tenant: "acme",
+			scopes: []model.Scope{model.ScopeMessagesRead},
+		},
+		{
+			name: "scp fallback",
+			claims: func(c jwt.MapClaims) {
+				delete(c, "scope")
+				c["scp"] = []string{"messages:read", "audit:read"}
+			},
+			tenant: "acme",
+			scopes: []model.Scope{model.ScopeMessagesRead, model.ScopeAuditRead},
+		},
+		{
+			name: "mapped roles",
+			claims: func(c jwt.MapClaims) {
+				c["roles"] = []any{"admin", "viewer", "guest", 7}
+			},
+			validator: func(v *TokenValidator) {
+				v.scopesClaim = "roles"
+				v.scopeMap = map[string][]model.Scope{
+					"admin":  {model.ScopeSchedulerAdmin, model.ScopeMessagesRead},
+					"viewer": {model.ScopeMessagesRead},
+				}
+			},
+			tenant: "acme",
+			scopes: []model.Scope{model.ScopeSchedulerAdmin, model.ScopeMessagesRead},
+		},
+		{
+			name: "custom scopes claim has no scp fallback",
+			claims: func(c jwt.MapClaims) {
+				delete(c, "scope")
+				c["scp"] = "messages:read"
+			},
+			validator: func(v *TokenValidator) { v.scopesClaim = "roles" },
+			tenant:    "acme",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestValidator(t, rsaKey, ecKey)
+			if tt.validator != nil {
+				tt.validator(v)
+			}
+			claims := validClaims()
+			if tt.claims != nil {
+				tt.claims(claims)
+			}
+			sign := rsaKey.sign
+			if tt.sign != nil {
+				sign = tt.sign
+			}
+
+			principal, err := v.Validate(context.Background(), sign(t, claims))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Validate returned %+v, want an error", principal)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate returned error: %v", err)
+			}
+
+			if principal.Subject != "jwt:user-1" {
+				t.Errorf("Subject = %q, want jwt:user-1", principal.Subject)
+			}
+			if principal.APIKeyID != nil {
+				t.Errorf("APIKeyID = %v, want nil", *principal.APIKeyID)
+			}
+			if principal.TenantID != tt.tenant {
+				t.Errorf("TenantID = %q, want %q", principal.TenantID, tt.tenant)
+			}
+			if !reflect.DeepEqual(principal.Scopes, tt.scopes) {
+				t.Errorf("Scopes = %v, want %v", principal.Scopes, tt.scopes)
+			}
+		})
+	}
+}
+
+func TestParseScopeMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string][]model.Scope
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: map[string][]model.Scope{}},
+		{
+			name:  "single",
+			input: "admin=scheduler:admin",
+			want:  map[string][]model.Scope{"admin": {model.ScopeSchedulerAdmin}},
+		},
+		{
+			name:  "value listed twice grants both",
+			input: " admin = scheduler:admin , viewer=messages:read,admin=keys:admin,",
+			want: map[string][]model.Scope{
+				"admin":  {model.ScopeSchedulerAdmin, model.ScopeKeysAdmin},
+				"viewer": {model.ScopeMessagesRead},
+			},
+		},
+		{name: "missing scope", input: "admin", wantErr: true},
+		{name: "unknown scope", input: "admin=everything", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseScopeMap(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseScopeMap(%q) = %v, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseScopeMap(%q) returned error: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseScopeMap(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTokenValidatorFromEnv(t *testing.T) {
+	jwksFile := writeJWKS(t, newRSAKey(t, "rsa"))
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		disabled bool
+		wantErr  bool
+	}{
+		{name: "disabled without a key set", disabled: true},
+		{name: "key set file", env: map[string]string{"JWT_JWKS_FILE": jwksFile}},
+		{name: "missing key set file", env: map[string]string{"JWT_JWKS_FILE": filepath.Join(t.TempDir(), "missing.json")}, wantErr: true},
+		{name: "invalid scope map", env: map[string]string{"JWT_JWKS_FILE": jwksFile, "JWT_SCOPE_MAP": "admin"}, wantErr: true},
+		{name: "default tenant", env: map[string]string{"JWT_JWKS_FILE": jwksFile, "JWT_DEFAULT_TENANT": "shared"}},
+		{name: "invalid default tenant", env: map[string]string{"JWT_JWKS_FILE": jwksFile, "JWT_DEFAULT_TENANT": "Shared"}, wantErr: true},
+		{name: "operator default tenant", env: map[string]string{"JWT_JWKS_FILE": jwksFile, "JWT_DEFAULT_TENANT": model.DefaultTenant}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"JWT_JWKS_URL", "JWT_JWKS_FILE", "JWT_ISSUER", "JWT_AUDIENCE", "JWT_SCOPES_CLAIM", "JWT_SCOPE_MAP", "JWT_TENANT_CLAIM", "JWT_DEFAULT_TENANT"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			v, err := NewTokenValidatorFromEnv(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewTokenValidatorFromEnv returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTokenValidatorFromEnv returned error: %v", err)
+			}
+			if (v == nil) != tt.disabled {
+				t.Fatalf("NewTokenValidatorFromEnv = %v, want disabled %v", v, tt.disabled)
+			}
+			if v != nil {
+				if v.scopesClaim != defaultScopesClaim || v.tenantClaim != defaultTenantClaim {
+					t.Errorf("claims = %q, %q, want the defaults", v.scopesClaim, v.tenantClaim)
+				}
+				if v.defaultTenant != tt.env["JWT_DEFAULT_TENANT"] {
+					t.Errorf("defaultTenant = %q, want %q", v.defaultTenant, tt.env["JWT_DEFAULT_TENANT"])
+				}
+			}
+		})
+	}
+}
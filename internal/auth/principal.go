@@ -0,0 +1,34 @@
+package auth
+
+import "github.com/kubilayrn/ChronoGo/internal/model"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject identifies the caller, e.g. "api-key:3" or "jwt:<sub claim>".
+	Subject string
+	// APIKeyID is set when the caller used an issued API key.
+	APIKeyID *int
+	// TenantID is the tenant whose data the caller sees.
+	TenantID string
+	Scopes   []model.Scope
+}
+
+// Operator reports whether the caller belongs to the default tenant, which
+// operates the deployment.
+func (p *Principal) Operator() bool {
+	return p.TenantID == model.DefaultTenant
+}
+
+// HasScope reports whether the caller was granted scope. Operator only scopes
+// are ignored for other tenants, so no tenant can affect another.
+func (p *Principal) HasScope(scope model.Scope) bool {
+	if scope.OperatorOnly() && !p.Operator() {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
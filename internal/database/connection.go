@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/kubilayrn/ChronoGo/internal/tracing"
 )
 
 var DB *pgxpool.Pool
@@ -16,6 +18,7 @@ func Connect(ctx context.Context, config *Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse connection string: %w", err)
 	}
+	poolConfig.ConnConfig.Tracer = tracing.NewQueryTracer()
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
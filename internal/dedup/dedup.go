@@ -0,0 +1,121 @@
+package dedup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/redis"
+)
+
+const cacheKeyPrefix = "dedup:"
+
+// minHMACKeyLength is the shortest DEDUP_HMAC_KEY accepted, in bytes.
+const minHMACKeyLength = 32
+
+// Deduplicator decides when two messages are the same notification: same
+// recipient and same dedup key within Window.
+type Deduplicator struct {
+	window time.Duration
+	// hmacKey keys the hashes of messages without a dedup key.
+	hmacKey []byte
+}
+
+// NewDeduplicatorFromEnv reads DEDUP_WINDOW_MINUTES and DEDUP_HMAC_KEY. Zero
+// minutes, the default, disables deduplication. With deduplication enabled
+// the key is required: hashes of content are stored next to the messages, and
+// without a secret they could be matched against guessed content.
+func NewDeduplicatorFromEnv() (*Deduplicator, error) {
+	_ = godotenv.Load()
+
+	minutes := 0
+	if value := os.Getenv("DEDUP_WINDOW_MINUTES"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			slog.Warn("Invalid value for DEDUP_WINDOW_MINUTES, deduplication disabled")
+		} else {
+			minutes = parsed
+		}
+	}
+
+	d := &Deduplicator{window: time.Duration(minutes) * time.Minute}
+	if !d.Enabled() {
+		return d, nil
+	}
+
+	d.hmacKey = []byte(os.Getenv("DEDUP_HMAC_KEY"))
+	if len(d.hmacKey) < minHMACKeyLength {
+		return nil, errors.New("DEDUP_HMAC_KEY must be set to at least 32 bytes when DEDUP_WINDOW_MINUTES is set")
+	}
+	return d, nil
+}
+
+func (d *Deduplicator) Enabled() bool {
+	return d.window > 0
+}
+
+func (d *Deduplicator) Window() time.Duration {
+	return d.window
+}
+
+// Key returns the client supplied key if there is one, otherwise an HMAC of
+// what the recipient would receive: the content, or the template, locale and
+// variables of a templated message.
+func (d *Deduplicator) Key(msg model.Message) string {
+	if msg.DedupKey != "" {
+		return msg.DedupKey
+	}
+
+	// json.Marshal sorts map keys, so equal variables hash equally.
+	data, _ := json.Marshal(struct {
+		Channel    model.Channel  `json:"channel"`
+		Content    string         `json:"content,omitempty"`
+		TemplateID *int           `json:"template_id,omitempty"`
+		Locale     string         `json:"locale,omitempty"`
+		Variables  map[string]any `json:"variables,omitempty"`
+	}{msg.Channel, msg.Content, msg.TemplateID, msg.Locale, msg.Variables})
+
+	mac := hmac.New(sha256.New, d.hmacKey)
+	mac.Write(data)
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Lookup is the Redis fast path: it returns the id of a message already
+// accepted for the tenant, recipient and dedup key of msg within the window. A
+// miss is not proof that there is no duplicate; the database decides.
+func (d *Deduplicator) Lookup(ctx context.Context, msg model.Message) (int, bool) {
+	if !d.Enabled() || redis.Client == nil {
+		return 0, false
+	}
+
+	id, err := redis.Client.Get(ctx, cacheKey(msg)).Int()
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// Remember records an accepted message for the fast path.
+func (d *Deduplicator) Remember(ctx context.Context, msg model.Message) {
+	if !d.Enabled() || redis.Client == nil {
+		return
+	}
+
+	if err := redis.Client.Set(ctx, cacheKey(msg), msg.ID, d.window).Err(); err != nil {
+		slog.WarnContext(ctx, "Failed to cache dedup key to Redis", "error", err)
+	}
+}
+
+func cacheKey(msg model.Message) string {
+	return fmt.Sprintf("%s%s:%s:%s", cacheKeyPrefix, msg.TenantID, msg.To, msg.DedupKey)
+}
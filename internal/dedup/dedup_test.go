@@ -0,0 +1,161 @@
+package dedup
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/redis"
+)
+
+var testHMACKey = strings.Repeat("k", minHMACKeyLength)
+
+func TestNewDeduplicatorFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		minutes string
+		hmacKey string
+		enabled bool
+		wantErr bool
+	}{
+		{name: "disabled by default"},
+		{name: "invalid window disables", minutes: "soon"},
+		{name: "negative window disables", minutes: "-5"},
+		{name: "enabled", minutes: "10", hmacKey: testHMACKey, enabled: true},
+		{name: "missing key", minutes: "10", wantErr: true},
+		{name: "short key", minutes: "10", hmacKey: "short", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DEDUP_WINDOW_MINUTES", tt.minutes)
+			t.Setenv("DEDUP_HMAC_KEY", tt.hmacKey)
+
+			d, err := NewDeduplicatorFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewDeduplicatorFromEnv returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewDeduplicatorFromEnv returned error: %v", err)
+			}
+			if d.Enabled() != tt.enabled {
+				t.Errorf("Enabled() = %v, want %v", d.Enabled(), tt.enabled)
+			}
+			if tt.enabled && d.Window() != 10*time.Minute {
+				t.Errorf("Window() = %v, want 10m", d.Window())
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	d := &Deduplicator{window: time.Minute, hmacKey: []byte(testHMACKey)}
+	templateID := 7
+	msg := model.Message{Channel: model.ChannelSMS, Content: "hello"}
+	templated := model.Message{
+		Channel:    model.ChannelSMS,
+		TemplateID: &templateID,
+		Locale:     "en",
+		Variables:  map[string]any{"name": "Ada", "code": "1234"},
+	}
+
+	key := d.Key(msg)
+	if !strings.HasPrefix(key, "hmac-sha256:") {
+		t.Errorf("Key() = %q, want an hmac-sha256 key", key)
+	}
+	if strings.Contains(key, "hello") {
+		t.Errorf("Key() = %q reveals the content", key)
+	}
+	if d.Key(msg) != key {
+		t.Error("Key() is not deterministic")
+	}
+
+	other := msg
+	other.Content = "hello!"
+	if d.Key(other) == key {
+		t.Error("Key() is equal for different content")
+	}
+	other = msg
+	other.Channel = model.ChannelEmail
+	if d.Key(other) == key {
+		t.Error("Key() is equal for different channels")
+	}
+
+	rekeyed := &Deduplicator{window: time.Minute, hmacKey: []byte(strings.Repeat("x", minHMACKeyLength))}
+	if rekeyed.Key(msg) == key {
+		t.Error("Key() does not depend on DEDUP_HMAC_KEY")
+	}
+
+	reordered := templated
+	reordered.Variables = map[string]any{"code": "1234", "name": "Ada"}
+	if d.Key(templated) != d.Key(reordered) {
+		t.Error("Key() depends on the order of variables")
+	}
+	changed := templated
+	changed.Variables = map[string]any{"name": "Ada", "code": "4321"}
+	if d.Key(templated) == d.Key(changed) {
+		t.Error("Key() is equal for different variables")
+	}
+
+	msg.DedupKey = "order-42"
+	if got := d.Key(msg); got != "order-42" {
+		t.Errorf("Key() = %q, want the client key", got)
+	}
+}
+
+func TestLookupRemember(t *testing.T) {
+	server := miniredis.RunT(t)
+	previous := redis.Client
+	redis.Client = goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		redis.Client.Close()
+		redis.Client = previous
+	})
+
+	ctx := context.Background()
+	d := &Deduplicator{window: 10 * time.Minute, hmacKey: []byte(testHMACKey)}
+	msg := model.Message{ID: 42, TenantID: model.DefaultTenant, To: "+905551234567", DedupKey: "order-42"}
+
+	if _, ok := d.Lookup(ctx, msg); ok {
+		t.Fatal("Lookup() found a key before Remember")
+	}
+
+	d.Remember(ctx, msg)
+	id, ok := d.Lookup(ctx, msg)
+	if !ok || id != 42 {
+		t.Errorf("Lookup() = %d, %v, want 42, true", id, ok)
+	}
+	if ttl := server.TTL(cacheKey(msg)); ttl != d.Window() {
+		t.Errorf("TTL = %v, want the window %v", ttl, d.Window())
+	}
+
+	otherTenant := msg
+	otherTenant.TenantID = "acme"
+	if _, ok := d.Lookup(ctx, otherTenant); ok {
+		t.Error("Lookup() found the key of another tenant")
+	}
+	otherRecipient := msg
+	otherRecipient.To = "+905559876543"
+	if _, ok := d.Lookup(ctx, otherRecipient); ok {
+		t.Error("Lookup() found the key of another recipient")
+	}
+
+	server.FastForward(d.Window())
+	if _, ok := d.Lookup(ctx, msg); ok {
+		t.Error("Lookup() found a key after the window")
+	}
+
+	disabled := &Deduplicator{}
+	disabled.Remember(ctx, otherTenant)
+	if server.Exists(cacheKey(otherTenant)) {
+		t.Error("Remember() cached a key with deduplication disabled")
+	}
+}
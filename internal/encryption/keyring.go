@@ -0,0 +1,286 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/joho/godotenv"
+)
+
+// keySize is the size of key encryption keys and of the per-row data keys,
+// both AES-256.
+const keySize = 32
+
+// maxKeyIDLength matches the content_key_id columns.
+const maxKeyIDLength = 64
+
+// envelopeVersion is the first byte of every envelope, so the format can
+// change without guessing what an old row holds.
+const envelopeVersion = 1
+
+// Keys is the keyring content is sealed with. It is nil when no keys are
+// configured, in which case content is stored as plain text.
+var Keys *Keyring
+
+// Keyring holds the key encryption keys by ID. New content is sealed with the
+// active key; the others are kept to open rows written before a rotation.
+type Keyring struct {
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+// LoadKeysFromEnv sets Keys from ENCRYPTION_KEYS and ENCRYPTION_KEYS_FILE,
+// which hold "id:base64-key" entries separated by commas or newlines, and
+// ENCRYPTION_ACTIVE_KEY_ID. The active key ID may be left out when there is
+// a single key. Keys stays nil when neither variable is set.
+func LoadKeysFromEnv() error {
+	_ = godotenv.Load()
+
+	entries := os.Getenv("ENCRYPTION_KEYS")
+	if path := os.Getenv("ENCRYPTION_KEYS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read ENCRYPTION_KEYS_FILE: %w", err)
+		}
+		entries += "\n" + string(data)
+	}
+	if strings.TrimSpace(entries) == "" {
+		return nil
+	}
+
+	keys, err := parseKeys(entries)
+	if err != nil {
+		return err
+	}
+
+	keyring, err := NewKeyring(keys, os.Getenv("ENCRYPTION_ACTIVE_KEY_ID"))
+	if err != nil {
+		return err
+	}
+
+	Keys = keyring
+	return nil
+}
+
+// parseKeys reads "id:base64-key" entries. Blank lines and lines starting
+// with # are skipped, so a key file can carry comments.
+func parseKeys(entries string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, entry := range strings.FieldsFunc(entries, func(r rune) bool { return r == ',' || r == '\n' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			// The entry is left out of the error, as it may be a bare key.
+			return nil, errors.New("invalid encryption key entry, expected id:base64-key")
+		}
+		id = strings.TrimSpace(id)
+		if _, duplicate := keys[id]; duplicate {
+			return nil, fmt.Errorf("encryption key %q is configured twice", id)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q is not valid base64: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
+
+// NewKeyring builds a keyring from 32-byte keys by ID. active may be empty
+// when there is exactly one key.
+func NewKeyring(keys map[string][]byte, active string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no encryption keys configured")
+	}
+	if active == "" {
+		if len(keys) > 1 {
+			return nil, errors.New("ENCRYPTION_ACTIVE_KEY_ID is required when more than one encryption key is configured")
+		}
+		for id := range keys {
+			active = id
+		}
+	}
+	if _, ok := keys[active]; !ok {
+		return nil, fmt.Errorf("active encryption key %q is not configured", active)
+	}
+
+	keyring := &Keyring{keys: make(map[string]cipher.AEAD, len(keys)), active: active}
+	for id, key := range keys {
+		if err := validateKeyID(id); err != nil {
+			return nil, err
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("encryption key %q must be %d bytes, got %d", id, keySize, len(key))
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", id, err)
+		}
+		keyring.keys[id] = aead
+	}
+	return keyring, nil
+}
+
+func validateKeyID(id string) error {
+	if id == "" || len(id) > maxKeyIDLength {
+		return fmt.Errorf("encryption key ID %q must be 1 to %d characters", id, maxKeyIDLength)
+	}
+	for _, r := range id {
+		if unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return fmt.Errorf("encryption key ID %q must not contain spaces or control characters", id)
+		}
+	}
+	return nil
+}
+
+// ActiveKeyID is the ID of the key new content is sealed with.
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+// Seal encrypts plaintext under a fresh data key, which is itself encrypted
+// with the active key. It returns the base64 envelope and the active key ID,
+// which must be stored alongside it.
+//
+// The envelope is the version byte, the nonce and encrypted data key, then
+// the nonce and the encrypted content.
+func (k *Keyring) Seal(plaintext string) (string, string, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	wrapped, err := k.wrap(dataKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", "", err
+	}
+	sealed, err := seal(data, []byte(plaintext), nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	envelope := append([]byte{envelopeVersion}, wrapped...)
+	envelope = append(envelope, sealed...)
+	return base64.StdEncoding.EncodeToString(envelope), k.active, nil
+}
+
+// Open decrypts an envelope written by Seal with the key keyID.
+func (k *Keyring) Open(envelope, keyID string) (string, error) {
+	wrapped, sealed, err := splitEnvelope(envelope)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := k.unwrap(wrapped, keyID)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(data, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt content: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// Rewrap re-encrypts the data key of an envelope sealed with keyID under the
+// active key, leaving the content itself untouched. It returns the new
+// envelope and the active key ID.
+func (k *Keyring) Rewrap(envelope, keyID string) (string, string, error) {
+	wrapped, sealed, err := splitEnvelope(envelope)
+	if err != nil {
+		return "", "", err
+	}
+
+	dataKey, err := k.unwrap(wrapped, keyID)
+	if err != nil {
+		return "", "", err
+	}
+
+	rewrapped, err := k.wrap(dataKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	out := append([]byte{envelopeVersion}, rewrapped...)
+	out = append(out, sealed...)
+	return base64.StdEncoding.EncodeToString(out), k.active, nil
+}
+
+func (k *Keyring) wrap(dataKey []byte) ([]byte, error) {
+	return seal(k.keys[k.active], dataKey, []byte(k.active))
+}
+
+func (k *Keyring) unwrap(wrapped []byte, keyID string) ([]byte, error) {
+	kek, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("encryption key %q is not configured", keyID)
+	}
+	// The key ID is authenticated with the data key, so a row cannot be
+	// relabelled with another key's ID.
+	dataKey, err := open(kek, wrapped, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key with key %q: %w", keyID, err)
+	}
+	return dataKey, nil
+}
+
+// wrappedKeySize is the length of a nonce and an encrypted data key.
+const wrappedKeySize = 12 + keySize + 16
+
+func splitEnvelope(envelope string) ([]byte, []byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(envelope)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid encrypted content: %w", err)
+	}
+	if len(raw) < 1+wrappedKeySize || raw[0] != envelopeVersion {
+		return nil, nil, errors.New("invalid encrypted content: unknown envelope format")
+	}
+	return raw[1 : 1+wrappedKeySize], raw[1+wrappedKeySize:], nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext under a random nonce, which it prepends.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,212 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, keySize)
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name       string
+		keys       map[string][]byte
+		active     string
+		wantActive string
+		wantErr    bool
+	}{
+		{name: "single key without active id", keys: map[string][]byte{"k1": testKey(1)}, wantActive: "k1"},
+		{name: "active id", keys: map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, active: "k2", wantActive: "k2"},
+		{name: "no keys", keys: map[string][]byte{}, wantErr: true},
+		{name: "several keys without active id", keys: map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, wantErr: true},
+		{name: "active key not configured", keys: map[string][]byte{"k1": testKey(1)}, active: "k2", wantErr: true},
+		{name: "short key", keys: map[string][]byte{"k1": testKey(1)[:16]}, wantErr: true},
+		{name: "key id with space", keys: map[string][]byte{"k 1": testKey(1)}, wantErr: true},
+		{name: "key id too long", keys: map[string][]byte{strings.Repeat("k", maxKeyIDLength+1): testKey(1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring(tt.keys, tt.active)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewKeyring returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewKeyring returned error: %v", err)
+			}
+			if got := keyring.ActiveKeyID(); got != tt.wantActive {
+				t.Errorf("ActiveKeyID() = %q, want %q", got, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(testKey(1))
+
+	tests := []struct {
+		name    string
+		entries string
+		wantIDs []string
+		wantErr bool
+	}{
+		{name: "comma separated", entries: "k1:" + encoded + ", k2:" + encoded, wantIDs: []string{"k1", "k2"}},
+		{name: "file with comments", entries: "# rotated 2026-01\nk1:" + encoded + "\n\nk2:" + encoded + "\n", wantIDs: []string{"k1", "k2"}},
+		{name: "bare key", entries: encoded, wantErr: true},
+		{name: "duplicate id", entries: "k1:" + encoded + ",k1:" + encoded, wantErr: true},
+		{name: "invalid base64", entries: "k1:not base64", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := parseKeys(tt.entries)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("parseKeys returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseKeys returned error: %v", err)
+			}
+			if len(keys) != len(tt.wantIDs) {
+				t.Fatalf("parseKeys returned %d keys, want %d", len(keys), len(tt.wantIDs))
+			}
+			for _, id := range tt.wantIDs {
+				if !bytes.Equal(keys[id], testKey(1)) {
+					t.Errorf("key %q = %x, want %x", id, keys[id], testKey(1))
+				}
+			}
+		})
+	}
+}
+
+func TestKeyringSealOpen(t *testing.T) {
+	keyring, err := NewKeyring(map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plaintext := range []string{"", "Your code is 123456", "Merhaba dünya 👋", strings.Repeat("a", 1600)} {
+		envelope, keyID, err := keyring.Seal(plaintext)
+		if err != nil {
+			t.Fatalf("Seal(%q) returned error: %v", plaintext, err)
+		}
+		if keyID != "k1" {
+			t.Errorf("Seal(%q) key ID = %q, want k1", plaintext, keyID)
+		}
+		if plaintext != "" && strings.Contains(envelope, plaintext) {
+			t.Errorf("Seal(%q) envelope contains the plaintext", plaintext)
+		}
+
+		got, err := keyring.Open(envelope, keyID)
+		if err != nil {
+			t.Fatalf("Open returned error: %v", err)
+		}
+		if got != plaintext {
+			t.Errorf("Open = %q, want %q", got, plaintext)
+		}
+	}
+
+	first, _, _ := keyring.Seal("same")
+	second, _, _ := keyring.Seal("same")
+	if first == second {
+		t.Error("Seal returned the same envelope twice for the same plaintext")
+	}
+}
+
+func TestKeyringOpenErrors(t *testing.T) {
+	keyring, err := NewKeyring(map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, _, err := keyring.Seal("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(envelope)
+	tamper := func(i int) string {
+		changed := bytes.Clone(raw)
+		changed[i] ^= 1
+		return base64.StdEncoding.EncodeToString(changed)
+	}
+
+	tests := []struct {
+		name     string
+		envelope string
+		keyID    string
+	}{
+		{"unknown key id", envelope, "k3"},
+		{"relabelled with another key id", envelope, "k2"},
+		{"tampered data key", tamper(1 + 12), "k1"},
+		{"tampered content", tamper(len(raw) - 1), "k1"},
+		{"unknown version", tamper(0), "k1"},
+		{"truncated", base64.StdEncoding.EncodeToString(raw[:wrappedKeySize]), "k1"},
+		{"not base64", "not base64", "k1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := keyring.Open(tt.envelope, tt.keyID); err == nil {
+				t.Errorf("Open = %q, want error", got)
+			}
+		})
+	}
+}
+
+func TestKeyringRewrap(t *testing.T) {
+	before, err := NewKeyring(map[string][]byte{"old": testKey(1)}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := NewKeyring(map[string][]byte{"old": testKey(1), "new": testKey(2)}, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, keyID, err := before.Seal("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewrapped, newKeyID, err := after.Rewrap(envelope, keyID)
+	if err != nil {
+		t.Fatalf("Rewrap returned error: %v", err)
+	}
+	if newKeyID != "new" {
+		t.Errorf("Rewrap key ID = %q, want new", newKeyID)
+	}
+
+	got, err := after.Open(rewrapped, newKeyID)
+	if err != nil {
+		t.Fatalf("Open of rewrapped envelope returned error: %v", err)
+	}
+	if got != "secret" {
+		t.Errorf("Open of rewrapped envelope = %q, want secret", got)
+	}
+
+	// Only the data key is re-encrypted; the content is carried over as is.
+	oldRaw, _ := base64.StdEncoding.DecodeString(envelope)
+	newRaw, _ := base64.StdEncoding.DecodeString(rewrapped)
+	if !bytes.Equal(oldRaw[1+wrappedKeySize:], newRaw[1+wrappedKeySize:]) {
+		t.Error("Rewrap changed the encrypted content")
+	}
+	if bytes.Equal(oldRaw[1:1+wrappedKeySize], newRaw[1:1+wrappedKeySize]) {
+		t.Error("Rewrap did not change the wrapped data key")
+	}
+
+	if _, err := after.Open(rewrapped, "old"); err == nil {
+		t.Error("Open of rewrapped envelope with the old key ID returned no error")
+	}
+	if _, _, err := after.Rewrap(envelope, "missing"); err == nil {
+		t.Error("Rewrap with an unknown key ID returned no error")
+	}
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/auth"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+)
+
+// CreateAPIKey godoc
+// @Summary      Issue an API key
+// @Description  Create a key with the given scopes. The key is only returned in this response.
+// @Description  Keys belong to the caller's tenant; only callers of the default tenant may issue keys for another tenant.
+// @Description  A key can only be given scopes the caller holds itself.
+// @Tags         keys
+// @Accept       json
+// @Produce      json
+// @Param        key  body      APIKeyRequest  true  "API key"
+// @Success      201  {object}  CreateAPIKeyResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /keys [post]
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var req APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid API key payload: " + err.Error(),
+		})
+		return
+	}
+
+	principal := principalFrom(c)
+	scopes := make([]model.Scope, len(req.Scopes))
+	for i, s := range req.Scopes {
+		scope, err := model.ParseScope(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		// Otherwise keys:admin alone would grant every other scope.
+		if principal == nil || !principal.HasScope(scope) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "Cannot issue a key with scope " + s + ", which you do not hold",
+			})
+			return
+		}
+		scopes[i] = scope
+	}
+
+	tenantID := callerTenant(c)
+	if req.TenantID != "" && req.TenantID != tenantID {
+		if tenantID != model.DefaultTenant {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "Keys can only be issued for your own tenant",
+			})
+			return
+		}
+		tenantID = req.TenantID
+	}
+
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to generate API key",
+		})
+		return
+	}
+
+	key := &model.APIKey{Name: req.Name, TenantID: tenantID, Prefix: prefix, Scopes: scopes}
+	if err := h.apiKeyRepo.CreateAPIKey(c.Request.Context(), key, hash); err != nil {
+		if errors.Is(err, repository.ErrTenantNotFound) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Tenant not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to create API key",
+		})
+		return
+	}
+	h.audit(c, model.AuditAPIKeyCreate, key.ID, nil, key)
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(*key),
+		Key:            secret,
+	})
+}
+
+// ListAPIKeys godoc
+// @Summary      List API keys
+// @Description  Retrieve issued keys, including revoked ones, newest first. Keys themselves are never returned.
+// @Description  Callers of the default tenant see the keys of every tenant, others only their own.
+// @Tags         keys
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  ListAPIKeysResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /keys [get]
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeyRepo.ListAPIKeys(c.Request.Context(), tenantFilter(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch API keys",
+		})
+		return
+	}
+
+	keyResponses := make([]APIKeyResponse, len(keys))
+	for i, key := range keys {
+		keyResponses[i] = toAPIKeyResponse(key)
+	}
+
+	c.JSON(http.StatusOK, ListAPIKeysResponse{
+		Keys:  keyResponses,
+		Total: len(keyResponses),
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  The key stops authenticating immediately.
+// @Tags         keys
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "API key ID"
+// @Success      200  {object}  APIKeyResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid API key ID",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	before, err := h.apiKeyRepo.GetAPIKey(ctx, tenantFilter(c), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "API key not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch API key",
+		})
+		return
+	}
+
+	key, err := h.apiKeyRepo.RevokeAPIKey(ctx, tenantFilter(c), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "API key not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to revoke API key",
+		})
+		return
+	}
+	h.audit(c, model.AuditAPIKeyRevoke, id, before, key)
+
+	c.JSON(http.StatusOK, toAPIKeyResponse(*key))
+}
+
+// tenantFilter is the tenant whose keys and audit entries the caller sees:
+// its own, or every tenant's for callers of the default tenant.
+func tenantFilter(c *gin.Context) string {
+	tenantID := callerTenant(c)
+	if tenantID == model.DefaultTenant {
+		return ""
+	}
+	return tenantID
+}
+
+func toAPIKeyResponse(key model.APIKey) APIKeyResponse {
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	resp := APIKeyResponse{
+		ID:        key.ID,
+		TenantID:  key.TenantID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    scopes,
+		CreatedAt: key.CreatedAt.Format(time.RFC3339),
+	}
+	if key.LastUsedAt != nil {
+		resp.LastUsedAt = key.LastUsedAt.Format(time.RFC3339)
+	}
+	if key.RevokedAt != nil {
+		resp.RevokedAt = key.RevokedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/redact"
+)
+
+// audit records a state change made by the caller. before and after are
+// stored as JSON; nil means the target did not exist. Failures are logged
+// rather than returned, since the change itself has already been made.
+func (h *Handler) audit(c *gin.Context, action model.AuditAction, targetID any, before, after any) {
+	entry := &model.AuditEntry{
+		TenantID:   callerTenant(c),
+		Actor:      callerSubject(c),
+		Action:     action,
+		TargetType: action.TargetType(),
+	}
+	if targetID != nil {
+		entry.TargetID = fmt.Sprint(targetID)
+	}
+
+	ctx := c.Request.Context()
+	var err error
+	if entry.Before, err = json.Marshal(before); err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal audit value", "action", action, "error", err)
+	}
+	if entry.After, err = json.Marshal(after); err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal audit value", "action", action, "error", err)
+	}
+
+	if err := h.auditRepo.RecordAudit(ctx, entry); err != nil {
+		// Suppressions are identified by recipient, which the log handler masks.
+		target := slog.String("target_id", entry.TargetID)
+		if entry.TargetType == "suppression" {
+			target = slog.String("recipient", entry.TargetID)
+		}
+		slog.ErrorContext(ctx, "Failed to record audit entry",
+			"action", action, target, "actor", entry.Actor, "error", err)
+	}
+}
+
+// ListAuditEntries godoc
+// @Summary      List audit log entries
+// @Description  Retrieve recorded state changes, newest first. Callers of the default tenant see every tenant's entries,
+// @Description  others only their own. since and until are RFC 3339 times. Recipients of contacts and
+// @Description  suppressions are masked in target_id, before and after unless the caller has the pii:read scope.
+// @Tags         audit
+// @Accept       json
+// @Produce      json
+// @Param        actor        query     string  false  "Actor, e.g. api-key:3"
+// @Param        action       query     string  false  "Action, e.g. scheduler.stop"
+// @Param        target_type  query     string  false  "Target type, e.g. campaign"
+// @Param        target_id    query     string  false  "Target ID"
+// @Param        since        query     string  false  "Only entries at or after this time"
+// @Param        until        query     string  false  "Only entries before this time"
+// @Param        limit        query     int     false  "Page size (max 1000)"  default(100)
+// @Param        offset       query     int     false  "Offset"                default(0)
+// @Success      200          {object}  ListAuditEntriesResponse
+// @Failure      400          {object}  ErrorResponse
+// @Failure      401          {object}  ErrorResponse
+// @Failure      403          {object}  ErrorResponse
+// @Failure      429          {object}  ErrorResponse
+// @Failure      500          {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /audit [get]
+func (h *Handler) ListAuditEntries(c *gin.Context) {
+	limit, offset, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	filter := model.AuditFilter{
+		TenantID:   tenantFilter(c),
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+	}
+	if filter.Since, ok = timeParam(c, "since"); !ok {
+		return
+	}
+	if filter.Until, ok = timeParam(c, "until"); !ok {
+		return
+	}
+
+	entries, err := h.auditRepo.ListAuditEntries(c.Request.Context(), filter, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch audit log",
+		})
+		return
+	}
+
+	unmasked := callerSeesPII(c)
+	entryResponses := make([]AuditEntryResponse, len(entries))
+	for i, entry := range entries {
+		entryResponses[i] = toAuditEntryResponse(entry, unmasked)
+	}
+
+	c.JSON(http.StatusOK, ListAuditEntriesResponse{
+		Entries: entryResponses,
+		Total:   len(entryResponses),
+	})
+}
+
+// timeParam reads an optional RFC 3339 query parameter, responding with 400
+// when it is invalid.
+func timeParam(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("%s must be an RFC 3339 time", name),
+		})
+		return nil, false
+	}
+
+	t = t.UTC()
+	return &t, true
+}
+
+// toAuditEntryResponse converts entry. Unless unmasked is set, the recipients
+// of contacts and suppressions are masked, both in before and after and where
+// a suppression's recipient is the target.
+func toAuditEntryResponse(entry model.AuditEntry, unmasked bool) AuditEntryResponse {
+	resp := AuditEntryResponse{
+		ID:         entry.ID,
+		TenantID:   entry.TenantID,
+		Actor:      entry.Actor,
+		Action:     string(entry.Action),
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     entry.Before,
+		After:      entry.After,
+		CreatedAt:  entry.CreatedAt.Format(time.RFC3339),
+	}
+	if !unmasked {
+		if entry.TargetType == "suppression" && entry.TargetID != "" {
+			resp.TargetID = redact.Recipient(entry.TargetID)
+		}
+		resp.Before = maskRecipients(entry.Before)
+		resp.After = maskRecipients(entry.After)
+	}
+	return resp
+}
+
+// maskRecipients masks every "recipient" field in an audited value, at any
+// depth so imported lists are covered too. A value that cannot be decoded is
+// left out rather than returned unmasked.
+func maskRecipients(value json.RawMessage) json.RawMessage {
+	if len(value) == 0 {
+		return value
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil
+	}
+	maskRecipientFields(decoded)
+
+	masked, err := json.Marshal(decoded)
+	if err != nil {
+		return nil
+	}
+	return masked
+}
+
+func maskRecipientFields(value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if recipient, ok := field.(string); ok && key == "recipient" {
+				v[key] = redact.Recipient(recipient)
+				continue
+			}
+			maskRecipientFields(field)
+		}
+	case []any:
+		for _, item := range v {
+			maskRecipientFields(item)
+		}
+	}
+}
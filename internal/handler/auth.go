@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/auth"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+)
+
+// APIKeyHeader carries the caller's API key.
+const APIKeyHeader = "X-API-Key"
+
+// bearerPrefix starts an Authorization header carrying a JWT.
+const bearerPrefix = "Bearer "
+
+// principalKey is the gin context key of the authenticated *auth.Principal.
+const principalKey = "principal"
+
+// Authenticate rejects requests without a valid API key or bearer token and
+// stores the caller for RequireScope. adminKey, when set, is a bootstrap key
+// with every scope, used to issue the first keys. tokens may be nil, in which
+// case bearer tokens are not accepted.
+func (h *Handler) Authenticate(adminKey string, tokens *auth.TokenValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorization := c.GetHeader("Authorization")
+		if tokens != nil && strings.HasPrefix(authorization, bearerPrefix) {
+			principal, err := tokens.Validate(c.Request.Context(), strings.TrimPrefix(authorization, bearerPrefix))
+			if err != nil {
+				slog.InfoContext(c.Request.Context(), "Rejected bearer token", "error", err)
+				abortUnauthorized(c, "Invalid bearer token")
+				return
+			}
+			c.Set(principalKey, principal)
+			c.Next()
+			return
+		}
+
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" {
+			abortUnauthorized(c, "Missing API key")
+			return
+		}
+
+		if adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1 {
+			c.Set(principalKey, &auth.Principal{Subject: "admin", TenantID: model.DefaultTenant, Scopes: model.Scopes})
+			c.Next()
+			return
+		}
+
+		apiKey, err := h.apiKeyRepo.GetActiveAPIKey(c.Request.Context(), auth.HashAPIKey(key))
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			abortUnauthorized(c, "Invalid API key")
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to authenticate API key", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to authenticate",
+			})
+			return
+		}
+
+		c.Set(principalKey, &auth.Principal{
+			Subject:  fmt.Sprintf("api-key:%d", apiKey.ID),
+			APIKeyID: &apiKey.ID,
+			TenantID: apiKey.TenantID,
+			Scopes:   apiKey.Scopes,
+		})
+		c.Next()
+	}
+}
+
+// RequireScope rejects callers that were not granted scope.
+func RequireScope(scope model.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := principalFrom(c)
+		if principal == nil || !principal.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{
+				Error: fmt.Sprintf("Missing scope %s", scope),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// principalFrom returns the caller stored by Authenticate, or nil on routes
+// without authentication.
+func principalFrom(c *gin.Context) *auth.Principal {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil
+	}
+	principal, _ := value.(*auth.Principal)
+	return principal
+}
+
+// callerSubject returns the subject of the caller, recorded on what it
+// creates, or "" on routes without authentication.
+func callerSubject(c *gin.Context) string {
+	if principal := principalFrom(c); principal != nil {
+		return principal.Subject
+	}
+	return ""
+}
+
+// callerTenant returns the tenant whose data the caller sees.
+func callerTenant(c *gin.Context) string {
+	if principal := principalFrom(c); principal != nil && principal.TenantID != "" {
+		return principal.TenantID
+	}
+	return model.DefaultTenant
+}
+
+// callerSeesPII reports whether the caller may see message recipients and
+// content unmasked.
+func callerSeesPII(c *gin.Context) bool {
+	principal := principalFrom(c)
+	return principal != nil && principal.HasScope(model.ScopePIIRead)
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+		Error: message,
+	})
+}
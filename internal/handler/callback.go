@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/redis"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+)
+
+// CallbackAuthHeader carries the shared secret the provider sends with
+// delivery receipts. It mirrors the header we send to the webhook.
+const CallbackAuthHeader = "x-ins-auth-key"
+
+// webhookTenantKey is the gin context key of the tenant whose own webhook a
+// delivery receipt comes from. It is not set for receipts from the shared
+// webhook.
+const webhookTenantKey = "webhook_tenant"
+
+// CallbackAuth rejects requests whose CallbackAuthHeader does not match authKey,
+// the key of the provider behind the shared webhook.
+func CallbackAuth(authKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !validCallbackKey(c, authKey) {
+			abortInvalidCallbackCredentials(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// TenantCallbackAuth rejects requests whose CallbackAuthHeader does not match
+// the webhook auth key of the tenant in the path, and limits the receipt to
+// that tenant's messages. Tenants without their own webhook get their receipts
+// through the shared callback instead.
+func (h *Handler) TenantCallbackAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant, err := h.tenantRepo.GetTenant(c.Request.Context(), c.Param("tenant"))
+		if errors.Is(err, repository.ErrTenantNotFound) {
+			abortInvalidCallbackCredentials(c)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to authenticate delivery receipt", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to authenticate",
+			})
+			return
+		}
+		if tenant.WebhookURL == "" || tenant.WebhookAuthKey == "" || !validCallbackKey(c, tenant.WebhookAuthKey) {
+			abortInvalidCallbackCredentials(c)
+			return
+		}
+
+		c.Set(webhookTenantKey, tenant.ID)
+		c.Next()
+	}
+}
+
+func validCallbackKey(c *gin.Context, authKey string) bool {
+	provided := c.GetHeader(CallbackAuthHeader)
+	return provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(authKey)) == 1
+}
+
+func abortInvalidCallbackCredentials(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+		Error: "Invalid callback credentials",
+	})
+}
+
+// ReceiveDeliveryReceipt godoc
+// @Summary      Receive a delivery receipt
+// @Description  Callback for the provider behind the shared webhook to report delivered, undelivered or read status for a message sent earlier, authenticated with CALLBACK_AUTH_KEY. Only messages of tenants without their own webhook are matched.
+// @Tags         callbacks
+// @Accept       json
+// @Produce      json
+// @Param        x-ins-auth-key  header    string                 true  "Callback auth key"
+// @Param        receipt         body      DeliveryReceiptRequest true  "Delivery receipt"
+// @Success      200  {object}  DeliveryReceiptResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /callbacks/delivery-receipts [post]
+func (h *Handler) ReceiveDeliveryReceipt(c *gin.Context) {
+	h.receiveDeliveryReceipt(c)
+}
+
+// ReceiveTenantDeliveryReceipt godoc
+// @Summary      Receive a delivery receipt for a tenant's webhook
+// @Description  Callback for the provider behind a tenant's own webhook, authenticated with the tenant's webhook auth key. Only the tenant's messages are matched.
+// @Tags         callbacks
+// @Accept       json
+// @Produce      json
+// @Param        tenant          path      string                 true  "Tenant ID"
+// @Param        x-ins-auth-key  header    string                 true  "Tenant webhook auth key"
+// @Param        receipt         body      DeliveryReceiptRequest true  "Delivery receipt"
+// @Success      200  {object}  DeliveryReceiptResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /callbacks/{tenant}/delivery-receipts [post]
+func (h *Handler) ReceiveTenantDeliveryReceipt(c *gin.Context) {
+	h.receiveDeliveryReceipt(c)
+}
+
+func (h *Handler) receiveDeliveryReceipt(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req DeliveryReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid delivery receipt payload",
+		})
+		return
+	}
+
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil || messageID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid messageId",
+		})
+		return
+	}
+
+	status := model.MessageStatus(req.Status)
+	if !status.IsDeliveryReceipt() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Status must be one of delivered, undelivered, read",
+		})
+		return
+	}
+
+	at := time.Now()
+	if req.Timestamp != nil {
+		at = *req.Timestamp
+	}
+
+	id, err := h.resolveProviderMessageID(ctx, c.GetString(webhookTenantKey), messageID)
+	if err != nil {
+		if errors.Is(err, repository.ErrMessageNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Message not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to look up message",
+		})
+		return
+	}
+
+	applied, err := h.messageRepo.UpdateDeliveryStatus(ctx, id, status, at)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to update delivery status",
+		})
+		return
+	}
+
+	if !applied {
+		slog.InfoContext(ctx, "Ignored out-of-order delivery receipt", "status", status, "id", id, "message_id", messageID)
+	}
+
+	c.JSON(http.StatusOK, DeliveryReceiptResponse{
+		ID:      id,
+		Status:  string(status),
+		Applied: applied,
+	})
+}
+
+// resolveProviderMessageID maps the provider messageId to our row id, using the
+// Redis cache written at send time before falling back to the database. Only
+// messages sent through webhookTenant's webhook, or the shared webhook when it
+// is empty, are matched.
+func (h *Handler) resolveProviderMessageID(ctx context.Context, webhookTenant string, messageID uuid.UUID) (int, error) {
+	if redis.Client != nil {
+		cached, err := redis.GetCachedMessage(ctx, messageID)
+		if err == nil && cached.ID > 0 && cached.WebhookTenant == webhookTenant {
+			return cached.ID, nil
+		}
+	}
+
+	msg, err := h.messageRepo.GetMessageByProviderID(ctx, webhookTenant, messageID)
+	if err != nil {
+		return 0, err
+	}
+	return msg.ID, nil
+}
@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+)
+
+const maxCampaignRecipients = 10000
+
+// CreateCampaign godoc
+// @Summary      Create a campaign
+// @Description  Queue the template for every recipient as one campaign. Recipient variables override the
+// @Description  shared ones. Either the campaign and all its messages are created or nothing is.
+// @Description  Instead of recipients, a segment targets the contacts with the given tags; their
+// @Description  attributes are available to the template as .contact.
+// @Description  Recipients of duplicates are masked unless the caller has the pii:read scope.
+// @Tags         campaigns
+// @Accept       json
+// @Produce      json
+// @Param        campaign         body      CreateCampaignRequest  true   "Campaign"
+// @Param        Idempotency-Key  header    string                 false  "Replays the first response for retries with the same body"
+// @Success      201  {object}  CampaignResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /campaigns [post]
+func (h *Handler) CreateCampaign(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid campaign payload: " + err.Error(),
+		})
+		return
+	}
+
+	priority, err := model.ParsePriority(req.Priority)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	recipients, ok := h.resolveRecipients(c, req.Recipients, req.Segment, true, maxCampaignRecipients)
+	if !ok {
+		return
+	}
+
+	messages, ok := h.prepareRecipients(c, model.Message{
+		Channel:    model.Channel(req.Channel),
+		Priority:   priority,
+		TemplateID: req.TemplateID,
+		Variables:  req.Variables,
+		Critical:   req.Critical,
+	}, recipients)
+	if !ok {
+		return
+	}
+
+	campaign := &model.Campaign{
+		TenantID:   callerTenant(c),
+		Name:       req.Name,
+		TemplateID: *req.TemplateID,
+		Channel:    messages[0].Channel,
+		Priority:   priority,
+	}
+	_, duplicates, err := h.createBatch(ctx, messages, callerSeesPII(c), func(pending []*model.Message) (map[int]int, error) {
+		return h.campaignRepo.CreateCampaign(ctx, campaign, pending, h.dedup.Window())
+	})
+	if err != nil {
+		respondCampaignError(c, err, "Failed to create campaign")
+		return
+	}
+	h.audit(c, model.AuditCampaignCreate, campaign.ID, nil, campaign)
+
+	stats, err := h.campaignRepo.GetCampaignStats(ctx, campaign.ID)
+	if err != nil {
+		respondCampaignError(c, err, "Failed to fetch campaign stats")
+		return
+	}
+
+	resp := toCampaignResponse(*campaign, *stats)
+	resp.Duplicates = duplicates
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListCampaigns godoc
+// @Summary      List campaigns
+// @Description  Retrieve campaigns with their progress, newest first
+// @Tags         campaigns
+// @Accept       json
+// @Produce      json
+// @Param        limit   query     int  false  "Page size (max 1000)"  default(100)
+// @Param        offset  query     int  false  "Offset"                default(0)
+// @Success      200     {object}  ListCampaignsResponse
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      403     {object}  ErrorResponse
+// @Failure      429     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /campaigns [get]
+func (h *Handler) ListCampaigns(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	limit, offset, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	campaigns, err := h.campaignRepo.ListCampaigns(ctx, callerTenant(c), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch campaigns",
+		})
+		return
+	}
+
+	campaignResponses := make([]CampaignResponse, len(campaigns))
+	for i, campaign := range campaigns {
+		stats, err := h.campaignRepo.GetCampaignStats(ctx, campaign.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to fetch campaign stats",
+			})
+			return
+		}
+		campaignResponses[i] = toCampaignResponse(campaign, *stats)
+	}
+
+	c.JSON(http.StatusOK, ListCampaignsResponse{
+		Campaigns: campaignResponses,
+		Total:     len(campaignResponses),
+	})
+}
+
+// GetCampaign godoc
+// @Summary      Get a campaign
+// @Description  Retrieve a campaign with message counts by status and send throughput
+// @Tags         campaigns
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Campaign ID"
+// @Success      200  {object}  CampaignResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /campaigns/{id} [get]
+func (h *Handler) GetCampaign(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, ok := campaignIDParam(c)
+	if !ok {
+		return
+	}
+
+	campaign, err := h.campaignRepo.GetCampaign(ctx, callerTenant(c), id)
+	if err != nil {
+		respondCampaignError(c, err, "Failed to fetch campaign")
+		return
+	}
+
+	h.respondCampaign(c, *campaign)
+}
+
+// PauseCampaign godoc
+// @Summary      Pause a campaign
+// @Description  Stop sending the campaign's unsent messages until it is resumed. Messages already being sent are not affected.
+// @Tags         campaigns
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Campaign ID"
+// @Success      200  {object}  CampaignResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /campaigns/{id}/pause [post]
+func (h *Handler) PauseCampaign(c *gin.Context) {
+	h.updateCampaignStatus(c, model.CampaignPaused, model.AuditCampaignPause)
+}
+
+// ResumeCampaign godoc
+// @Summary      Resume a campaign
+// @Description  Continue sending a paused campaign
+// @Tags         campaigns
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Campaign ID"
+// @Success      200  {object}  CampaignResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /campaigns/{id}/resume [post]
+func (h *Handler) ResumeCampaign(c *gin.Context) {
+	h.updateCampaignStatus(c, model.CampaignRunning, model.AuditCampaignResume)
+}
+
+// CancelCampaign godoc
+// @Summary      Cancel a campaign
+// @Description  Mark the campaign's unsent messages cancelled. Messages already being sent finish, and are
+// @Description  cancelled instead of retried if sending fails.
+// @Tags         campaigns
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Campaign ID"
+// @Success      200  {object}  CampaignResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /campaigns/{id}/cancel [post]
+func (h *Handler) CancelCampaign(c *gin.Context) {
+	h.updateCampaignStatus(c, model.CampaignCancelled, model.AuditCampaignCancel)
+}
+
+func (h *Handler) updateCampaignStatus(c *gin.Context, status model.CampaignStatus, action model.AuditAction) {
+	id, ok := campaignIDParam(c)
+	if !ok {
+		return
+	}
+
+	before, err := h.campaignRepo.GetCampaign(c.Request.Context(), callerTenant(c), id)
+	if err != nil {
+		respondCampaignError(c, err, "Failed to fetch campaign")
+		return
+	}
+
+	campaign, err := h.campaignRepo.UpdateCampaignStatus(c.Request.Context(), callerTenant(c), id, status)
+	if err != nil {
+		respondCampaignError(c, err, "Failed to update campaign")
+		return
+	}
+	h.audit(c, action, id, before, campaign)
+
+	h.respondCampaign(c, *campaign)
+}
+
+func (h *Handler) respondCampaign(c *gin.Context, campaign model.Campaign) {
+	stats, err := h.campaignRepo.GetCampaignStats(c.Request.Context(), campaign.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch campaign stats",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toCampaignResponse(campaign, *stats))
+}
+
+func campaignIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid campaign ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+func respondCampaignError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrCampaignNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Campaign not found"})
+	case errors.Is(err, repository.ErrCampaignTransition):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Campaign cannot change to this status from its current one"})
+	case errors.Is(err, repository.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Template not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fallback})
+	}
+}
+
+func toCampaignResponse(campaign model.Campaign, stats model.CampaignStats) CampaignResponse {
+	byStatus := make(map[string]int, len(stats.ByStatus))
+	for status, count := range stats.ByStatus {
+		byStatus[string(status)] = count
+	}
+
+	resp := CampaignResponse{
+		ID:         campaign.ID,
+		TenantID:   campaign.TenantID,
+		Name:       campaign.Name,
+		TemplateID: campaign.TemplateID,
+		Channel:    string(campaign.Channel),
+		Priority:   campaign.Priority.String(),
+		Status:     string(campaign.Status),
+		Stats: CampaignStatsResponse{
+			Total:        stats.Total,
+			ByStatus:     byStatus,
+			Sent:         stats.Sent,
+			PerMinute:    stats.PerMinute(),
+			SentLastHour: stats.SentLastHour,
+		},
+		CreatedAt: campaign.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: campaign.UpdatedAt.Format(time.RFC3339),
+	}
+	if stats.FirstSentAt != nil {
+		resp.Stats.FirstSentAt = stats.FirstSentAt.Format(time.RFC3339)
+	}
+	if stats.LastSentAt != nil {
+		resp.Stats.LastSentAt = stats.LastSentAt.Format(time.RFC3339)
+	}
+	return resp
+}
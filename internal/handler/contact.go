@@ -0,0 +1,390 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/quiethours"
+	"github.com/kubilayrn/ChronoGo/internal/redact"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+	"github.com/kubilayrn/ChronoGo/internal/templating"
+)
+
+// CreateContact godoc
+// @Summary      Create or update a contact
+// @Description  Save a contact of the caller's tenant. A contact with the same recipient is replaced.
+// @Description  The recipient is masked in the response unless the caller has the pii:read scope.
+// @Tags         contacts
+// @Accept       json
+// @Produce      json
+// @Param        contact  body      ContactRequest  true  "Contact"
+// @Success      201      {object}  ContactResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      429      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /contacts [post]
+func (h *Handler) CreateContact(c *gin.Context) {
+	var req ContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid contact payload: " + err.Error(),
+		})
+		return
+	}
+
+	contact, err := h.newContact(c, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	if err := h.contactRepo.UpsertContact(c.Request.Context(), contact); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to save contact",
+		})
+		return
+	}
+	h.audit(c, model.AuditContactCreate, contact.ID, nil, contact)
+
+	c.JSON(http.StatusCreated, toContactResponse(*contact, callerSeesPII(c)))
+}
+
+// ImportContacts godoc
+// @Summary      Bulk import contacts
+// @Description  Save many contacts at once, replacing contacts with the same recipient. Either every entry is imported or none is.
+// @Tags         contacts
+// @Accept       json
+// @Produce      json
+// @Param        contacts  body      ImportContactsRequest  true  "Contacts"
+// @Success      200       {object}  ImportContactsResponse
+// @Failure      400       {object}  ErrorResponse
+// @Failure      401       {object}  ErrorResponse
+// @Failure      403       {object}  ErrorResponse
+// @Failure      429       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /contacts/import [post]
+func (h *Handler) ImportContacts(c *gin.Context) {
+	var req ImportContactsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid contact import payload: " + err.Error(),
+		})
+		return
+	}
+
+	contacts := make([]*model.Contact, len(req.Contacts))
+	for i, entry := range req.Contacts {
+		contact, err := h.newContact(c, entry)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: fmt.Sprintf("contacts[%d]: %v", i, err),
+			})
+			return
+		}
+		contacts[i] = contact
+	}
+
+	if err := h.contactRepo.ImportContacts(c.Request.Context(), contacts); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to import contacts",
+		})
+		return
+	}
+	h.audit(c, model.AuditContactImport, nil, nil, contacts)
+
+	c.JSON(http.StatusOK, ImportContactsResponse{
+		Imported: len(contacts),
+	})
+}
+
+// ListContacts godoc
+// @Summary      List contacts
+// @Description  Retrieve contacts, optionally only those in a tag segment, oldest first. Recipients are masked
+// @Description  unless the caller has the pii:read scope.
+// @Tags         contacts
+// @Accept       json
+// @Produce      json
+// @Param        tag          query     []string  false  "Tags that must all be present"  collectionFormat(multi)
+// @Param        any_tag      query     []string  false  "Tags of which one must be present"  collectionFormat(multi)
+// @Param        exclude_tag  query     []string  false  "Tags that must be absent"  collectionFormat(multi)
+// @Param        limit        query     int       false  "Page size (max 1000)"  default(100)
+// @Param        offset       query     int       false  "Offset"                default(0)
+// @Success      200          {object}  ListContactsResponse
+// @Failure      400          {object}  ErrorResponse
+// @Failure      401          {object}  ErrorResponse
+// @Failure      403          {object}  ErrorResponse
+// @Failure      429          {object}  ErrorResponse
+// @Failure      500          {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /contacts [get]
+func (h *Handler) ListContacts(c *gin.Context) {
+	limit, offset, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	segment := model.Segment{
+		Tags:        normalizeTags(c.QueryArray("tag")),
+		AnyTags:     normalizeTags(c.QueryArray("any_tag")),
+		ExcludeTags: normalizeTags(c.QueryArray("exclude_tag")),
+	}
+
+	contacts, err := h.contactRepo.FindContacts(c.Request.Context(), callerTenant(c), segment, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch contacts",
+		})
+		return
+	}
+
+	unmasked := callerSeesPII(c)
+	contactResponses := make([]ContactResponse, len(contacts))
+	for i, contact := range contacts {
+		contactResponses[i] = toContactResponse(contact, unmasked)
+	}
+
+	c.JSON(http.StatusOK, ListContactsResponse{
+		Contacts: contactResponses,
+		Total:    len(contactResponses),
+	})
+}
+
+// GetContact godoc
+// @Summary      Get a contact
+// @Description  The recipient is masked unless the caller has the pii:read scope.
+// @Tags         contacts
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Contact ID"
+// @Success      200  {object}  ContactResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /contacts/{id} [get]
+func (h *Handler) GetContact(c *gin.Context) {
+	id, ok := contactIDParam(c)
+	if !ok {
+		return
+	}
+
+	contact, err := h.contactRepo.GetContact(c.Request.Context(), callerTenant(c), id)
+	if err != nil {
+		respondContactError(c, err, "Failed to fetch contact")
+		return
+	}
+
+	c.JSON(http.StatusOK, toContactResponse(*contact, callerSeesPII(c)))
+}
+
+// DeleteContact godoc
+// @Summary      Delete a contact
+// @Description  Messages already queued for the contact are not affected.
+// @Tags         contacts
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "Contact ID"
+// @Success      204
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /contacts/{id} [delete]
+func (h *Handler) DeleteContact(c *gin.Context) {
+	id, ok := contactIDParam(c)
+	if !ok {
+		return
+	}
+
+	before, err := h.contactRepo.GetContact(c.Request.Context(), callerTenant(c), id)
+	if err != nil {
+		respondContactError(c, err, "Failed to fetch contact")
+		return
+	}
+
+	if err := h.contactRepo.DeleteContact(c.Request.Context(), callerTenant(c), id); err != nil {
+		respondContactError(c, err, "Failed to delete contact")
+		return
+	}
+	h.audit(c, model.AuditContactDelete, id, before, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// resolveRecipients returns recipients, or the contacts in segment when it is
+// set. Contacts carry their attributes as the contact variable when templated
+// is true. At most limit contacts may match. It responds to the client and
+// returns false on error.
+func (h *Handler) resolveRecipients(
+	c *gin.Context,
+	recipients []RecipientInput,
+	segment *SegmentRequest,
+	templated bool,
+	limit int,
+) ([]RecipientInput, bool) {
+	if (len(recipients) == 0) == (segment == nil) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Exactly one of recipients and segment is required",
+		})
+		return nil, false
+	}
+	if segment == nil {
+		return recipients, true
+	}
+
+	seg := model.Segment{
+		Tags:        normalizeTags(segment.Tags),
+		AnyTags:     normalizeTags(segment.AnyTags),
+		ExcludeTags: normalizeTags(segment.ExcludeTags),
+	}
+	if len(seg.Tags) == 0 && len(seg.AnyTags) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "segment needs tags or any_tags",
+		})
+		return nil, false
+	}
+
+	contacts, err := h.contactRepo.FindContacts(c.Request.Context(), callerTenant(c), seg, limit+1, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch segment",
+		})
+		return nil, false
+	}
+	switch {
+	case len(contacts) == 0:
+		c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+			Error: "segment matches no contacts",
+		})
+		return nil, false
+	case len(contacts) > limit:
+		c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+			Error: fmt.Sprintf("segment matches more than %d contacts", limit),
+		})
+		return nil, false
+	}
+
+	resolved := make([]RecipientInput, len(contacts))
+	for i, contact := range contacts {
+		resolved[i] = contactRecipient(contact, templated)
+	}
+	return resolved, true
+}
+
+func contactRecipient(contact model.Contact, templated bool) RecipientInput {
+	recipient := RecipientInput{
+		To:       contact.Recipient,
+		Locale:   contact.Locale,
+		Timezone: contact.Timezone,
+	}
+	if templated {
+		recipient.Variables = map[string]any{"contact": contact.TemplateVariables()}
+	}
+	return recipient
+}
+
+func (h *Handler) newContact(c *gin.Context, req ContactRequest) (*model.Contact, error) {
+	recipient, err := h.recipients.NormalizeAny(req.Recipient)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid recipient: %w", err)
+	}
+
+	locale, err := templating.NormalizeLocale(req.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Timezone != "" {
+		if err := quiethours.ValidateTimezone(req.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	return &model.Contact{
+		TenantID:   callerTenant(c),
+		Recipient:  recipient,
+		Name:       req.Name,
+		Locale:     locale,
+		Timezone:   req.Timezone,
+		Attributes: req.Attributes,
+		Tags:       normalizeTags(req.Tags),
+	}, nil
+}
+
+// normalizeTags lowercases and trims tags and drops empty and repeated ones.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+func contactIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid contact ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+func respondContactError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, repository.ErrContactNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Contact not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fallback})
+}
+
+// toContactResponse converts contact, masking its recipient unless unmasked
+// is set.
+func toContactResponse(contact model.Contact, unmasked bool) ContactResponse {
+	resp := ContactResponse{
+		ID:         contact.ID,
+		Recipient:  contact.Recipient,
+		Name:       contact.Name,
+		Locale:     contact.Locale,
+		Timezone:   contact.Timezone,
+		Attributes: contact.Attributes,
+		Tags:       contact.Tags,
+		CreatedAt:  contact.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  contact.UpdatedAt.Format(time.RFC3339),
+	}
+	if !unmasked {
+		resp.Recipient = redact.Recipient(contact.Recipient)
+	}
+	return resp
+}
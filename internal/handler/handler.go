@@ -1,18 +1,60 @@
 package handler
 
 import (
+	"github.com/kubilayrn/ChronoGo/internal/dedup"
 	"github.com/kubilayrn/ChronoGo/internal/queue"
+	"github.com/kubilayrn/ChronoGo/internal/recipient"
 	"github.com/kubilayrn/ChronoGo/internal/repository"
+	"github.com/kubilayrn/ChronoGo/internal/templating"
 )
 
 type Handler struct {
-	messageRepo *repository.MessageRepository
-	scheduler   *queue.Scheduler
+	messageRepo     *repository.MessageRepository
+	attemptRepo     *repository.AttemptRepository
+	templateRepo    *repository.TemplateRepository
+	suppressionRepo *repository.SuppressionRepository
+	campaignRepo    *repository.CampaignRepository
+	contactRepo     *repository.ContactRepository
+	apiKeyRepo      *repository.APIKeyRepository
+	tenantRepo      *repository.TenantRepository
+	auditRepo       *repository.AuditRepository
+	retentionRepo   *repository.RetentionRepository
+	renderer        *templating.Renderer
+	recipients      *recipient.Registry
+	dedup           *dedup.Deduplicator
+	scheduler       *queue.Scheduler
 }
 
-func NewHandler(messageRepo *repository.MessageRepository, scheduler *queue.Scheduler) *Handler {
+func NewHandler(
+	messageRepo *repository.MessageRepository,
+	attemptRepo *repository.AttemptRepository,
+	templateRepo *repository.TemplateRepository,
+	suppressionRepo *repository.SuppressionRepository,
+	campaignRepo *repository.CampaignRepository,
+	contactRepo *repository.ContactRepository,
+	apiKeyRepo *repository.APIKeyRepository,
+	tenantRepo *repository.TenantRepository,
+	auditRepo *repository.AuditRepository,
+	retentionRepo *repository.RetentionRepository,
+	renderer *templating.Renderer,
+	recipients *recipient.Registry,
+	deduplicator *dedup.Deduplicator,
+	scheduler *queue.Scheduler,
+) *Handler {
 	return &Handler{
-		messageRepo: messageRepo,
-		scheduler:   scheduler,
+		messageRepo:     messageRepo,
+		attemptRepo:     attemptRepo,
+		templateRepo:    templateRepo,
+		suppressionRepo: suppressionRepo,
+		campaignRepo:    campaignRepo,
+		contactRepo:     contactRepo,
+		apiKeyRepo:      apiKeyRepo,
+		tenantRepo:      tenantRepo,
+		auditRepo:       auditRepo,
+		retentionRepo:   retentionRepo,
+		renderer:        renderer,
+		recipients:      recipients,
+		dedup:           deduplicator,
+		scheduler:       scheduler,
 	}
 }
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/redis"
+)
+
+const (
+	// IdempotencyKeyHeader lets clients retry a creation request safely.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from an earlier request.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyPendingMargin is how much longer than the request timeout a
+	// reservation is held, so it cannot expire while its request still runs.
+	idempotencyPendingMargin = time.Minute
+)
+
+// Idempotency stores the first response to a request with an
+// IdempotencyKeyHeader and replays it for repeated requests with the same key
+// and body. Reusing a key with a different body, or while the first request is
+// still running, is a conflict. Server errors are not stored so the request
+// can be retried. Without Redis a key cannot be honoured, so requests with
+// one are rejected with 503 rather than risk creating duplicates. A request
+// that never finishes holds its key for requestTimeout plus
+// idempotencyPendingMargin.
+func Idempotency(requestTimeout time.Duration) gin.HandlerFunc {
+	pendingTTL := requestTimeout + idempotencyPendingMargin
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Idempotency-Key must be at most 255 characters",
+			})
+			return
+		}
+		if redis.Client == nil {
+			slog.WarnContext(c.Request.Context(), "Rejected request with Idempotency-Key, Redis is not available")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResponse{
+				Error: "Idempotency-Key is not available, retry later or send the request without it",
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to read request body",
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+		// Keys are scoped to the caller and the endpoint so callers cannot
+		// see each other's responses and the same key can be used for
+		// different kinds of requests.
+		storeKey := c.FullPath() + ":" + key
+		if principal := principalFrom(c); principal != nil {
+			storeKey = principal.Subject + ":" + storeKey
+		}
+
+		stored, token, err := redis.ReserveIdempotencyKey(ctx, storeKey, requestHash, pendingTTL)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to reserve idempotency key", "error", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResponse{
+				Error: "Failed to check Idempotency-Key",
+			})
+			return
+		}
+		if token == "" {
+			replayIdempotentResponse(c, stored, requestHash)
+			return
+		}
+
+		// Released unless a response was stored, including when the handler
+		// panics, so a retry is not refused until the reservation expires.
+		// The request context may already be cancelled by then.
+		saved := false
+		defer func() {
+			if saved {
+				return
+			}
+			if err := redis.ReleaseIdempotencyKey(context.WithoutCancel(ctx), storeKey, token); err != nil {
+				slog.ErrorContext(ctx, "Failed to release idempotency key", "error", err)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		err = redis.StoreIdempotentResponse(context.WithoutCancel(ctx), storeKey, token, redis.IdempotentResponse{
+			RequestHash: requestHash,
+			StatusCode:  recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		})
+		if errors.Is(err, redis.ErrIdempotencyReservationLost) {
+			slog.WarnContext(ctx, "Idempotency key reservation expired before the response was stored")
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to store idempotent response", "error", err)
+			return
+		}
+		saved = true
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, stored *redis.IdempotentResponse, requestHash string) {
+	switch {
+	case stored.RequestHash != requestHash:
+		c.AbortWithStatusJSON(http.StatusConflict, ErrorResponse{
+			Error: "Idempotency-Key was already used with a different request body",
+		})
+	case stored.Pending:
+		c.AbortWithStatusJSON(http.StatusConflict, ErrorResponse{
+			Error: "A request with this Idempotency-Key is still in progress",
+		})
+	default:
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+		c.Abort()
+	}
+}
+
+// responseRecorder keeps a copy of the response body while writing it.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
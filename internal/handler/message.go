@@ -2,25 +2,484 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/quiethours"
+	"github.com/kubilayrn/ChronoGo/internal/redact"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+	"github.com/kubilayrn/ChronoGo/internal/sms"
+	"github.com/kubilayrn/ChronoGo/internal/templating"
 )
 
+const maxBatchRecipients = 1000
+
+// CreateMessage godoc
+// @Summary      Create a message
+// @Description  Queue a message for sending. Either content or template_id is required; templated
+// @Description  messages are rendered with variables at send time. Higher priority messages are sent first.
+// @Description  Messages to suppressed recipients are stored with status suppressed and never sent.
+// @Description  Non-critical messages are deferred while the recipient's time zone is in quiet hours.
+// @Description  With deduplication enabled, a message with the same recipient and dedup_key (or content
+// @Description  hash) as one accepted within the window is rejected with 409.
+// @Description  With contact_id instead of to, the contact's locale and time zone are used unless given
+// @Description  and its attributes are available to templates as .contact.
+// @Description  Templates and contacts of other tenants are reported as not found, and only the tenant's
+// @Description  suppressions apply.
+// @Description  The recipient and content are masked in the response, including a duplicate's recipient, unless
+// @Description  the caller has the pii:read scope.
+// @Tags         messages
+// @Accept       json
+// @Produce      json
+// @Param        message          body      CreateMessageRequest  true   "Message to send"
+// @Param        Idempotency-Key  header    string                false  "Replays the first response for retries with the same body"
+// @Success      201      {object}  MessageResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  DuplicateMessageResponse
+// @Failure      422      {object}  ErrorResponse
+// @Failure      429      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Failure      503      {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /messages [post]
+func (h *Handler) CreateMessage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid message payload: " + err.Error(),
+		})
+		return
+	}
+
+	priority, err := model.ParsePriority(req.Priority)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	if req.ContactID != nil {
+		if req.To != "" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Only one of to and contact_id may be set",
+			})
+			return
+		}
+		contact, err := h.contactRepo.GetContact(ctx, callerTenant(c), *req.ContactID)
+		if err != nil {
+			respondContactError(c, err, "Failed to fetch contact")
+			return
+		}
+		recipient := contactRecipient(*contact, req.TemplateID != nil)
+		req.To = recipient.To
+		req.Variables = mergeVariables(recipient.Variables, req.Variables)
+		if req.Locale == "" {
+			req.Locale = recipient.Locale
+		}
+		if req.Timezone == "" {
+			req.Timezone = recipient.Timezone
+		}
+	}
+
+	msg := model.Message{
+		To:         req.To,
+		Channel:    model.Channel(req.Channel),
+		Content:    req.Content,
+		Priority:   priority,
+		TemplateID: req.TemplateID,
+		Variables:  req.Variables,
+		Locale:     req.Locale,
+		Timezone:   req.Timezone,
+		Critical:   req.Critical,
+		DedupKey:   req.DedupKey,
+		CreatedBy:  callerSubject(c),
+		TenantID:   callerTenant(c),
+	}
+	if err := h.prepareMessage(ctx, &msg); err != nil {
+		respondPrepareError(c, err, "")
+		return
+	}
+	if existing, ok := h.dedup.Lookup(ctx, msg); ok {
+		respondDuplicate(c, msg, existing)
+		return
+	}
+	if err := h.applySuppressions(ctx, []*model.Message{&msg}); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to check suppressions",
+		})
+		return
+	}
+
+	if err := h.messageRepo.CreateMessage(ctx, &msg, h.dedup.Window()); err != nil {
+		var dupErr *repository.DuplicateError
+		if errors.As(err, &dupErr) {
+			respondDuplicate(c, msg, dupErr.MessageID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to create message",
+		})
+		return
+	}
+
+	h.dedup.Remember(ctx, msg)
+	// The audit log is readable without pii:read, so it keeps masked copies.
+	h.audit(c, model.AuditMessageCreate, msg.ID, nil, toMessageResponse(msg, false))
+
+	c.JSON(http.StatusCreated, toMessageResponse(msg, callerSeesPII(c)))
+}
+
+func respondDuplicate(c *gin.Context, msg model.Message, existing int) {
+	to := msg.To
+	if !callerSeesPII(c) {
+		to = redact.Recipient(to)
+	}
+	c.JSON(http.StatusConflict, DuplicateMessageResponse{
+		Error:     "Duplicate message",
+		To:        to,
+		MessageID: existing,
+	})
+}
+
+// CreateMessages godoc
+// @Summary      Create messages for many recipients
+// @Description  Queue the same content or template for every recipient. Templated messages are rendered in
+// @Description  each recipient's locale, falling back e.g. tr-TR -> tr -> default locale -> template body.
+// @Description  Recipient variables override the shared ones. Either every message is queued or none is.
+// @Description  Recipients skipped by deduplication are listed in duplicates instead of messages.
+// @Description  Instead of recipients, a segment targets the contacts with the given tags; their
+// @Description  attributes are available to templates as .contact.
+// @Description  Recipients, including those of duplicates, and content are masked in the response unless the
+// @Description  caller has the pii:read scope.
+// @Tags         messages
+// @Accept       json
+// @Produce      json
+// @Param        batch            body      CreateMessagesRequest  true   "Messages to send"
+// @Param        Idempotency-Key  header    string                 false  "Replays the first response for retries with the same body"
+// @Success      201    {object}  CreateMessagesResponse
+// @Failure      400    {object}  ErrorResponse
+// @Failure      401    {object}  ErrorResponse
+// @Failure      403    {object}  ErrorResponse
+// @Failure      404    {object}  ErrorResponse
+// @Failure      409    {object}  ErrorResponse
+// @Failure      422    {object}  ErrorResponse
+// @Failure      429    {object}  ErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Failure      503    {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /messages/batch [post]
+func (h *Handler) CreateMessages(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateMessagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid batch payload: " + err.Error(),
+		})
+		return
+	}
+
+	priority, err := model.ParsePriority(req.Priority)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	recipients, ok := h.resolveRecipients(c, req.Recipients, req.Segment, req.TemplateID != nil, maxBatchRecipients)
+	if !ok {
+		return
+	}
+
+	messages, ok := h.prepareRecipients(c, model.Message{
+		Channel:    model.Channel(req.Channel),
+		Content:    req.Content,
+		Priority:   priority,
+		TemplateID: req.TemplateID,
+		Variables:  req.Variables,
+		Critical:   req.Critical,
+	}, recipients)
+	if !ok {
+		return
+	}
+
+	created, duplicates, err := h.createBatch(ctx, messages, callerSeesPII(c), func(pending []*model.Message) (map[int]int, error) {
+		return h.messageRepo.CreateMessages(ctx, pending, h.dedup.Window())
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to create messages",
+		})
+		return
+	}
+
+	unmasked := callerSeesPII(c)
+	messageResponses := make([]MessageResponse, len(created))
+	audited := make([]MessageResponse, len(created))
+	for i, msg := range created {
+		messageResponses[i] = toMessageResponse(*msg, unmasked)
+		audited[i] = toMessageResponse(*msg, false)
+	}
+	if len(created) > 0 {
+		h.audit(c, model.AuditMessageCreateBatch, nil, nil, audited)
+	}
+
+	c.JSON(http.StatusCreated, CreateMessagesResponse{
+		Messages:   messageResponses,
+		Total:      len(messageResponses),
+		Duplicates: duplicates,
+	})
+}
+
+// prepareRecipients builds and prepares a copy of shared for every recipient,
+// with the recipient's variables merged over the shared ones, and applies
+// suppressions. It responds to the client and returns false on error.
+func (h *Handler) prepareRecipients(c *gin.Context, shared model.Message, recipients []RecipientInput) ([]*model.Message, bool) {
+	ctx := c.Request.Context()
+
+	messages := make([]*model.Message, len(recipients))
+	for i, recipient := range recipients {
+		msg := shared
+		msg.To = recipient.To
+		msg.Variables = mergeVariables(shared.Variables, recipient.Variables)
+		msg.Locale = recipient.Locale
+		msg.Timezone = recipient.Timezone
+		msg.DedupKey = recipient.DedupKey
+		msg.CreatedBy = callerSubject(c)
+		msg.TenantID = callerTenant(c)
+		if err := h.prepareMessage(ctx, &msg); err != nil {
+			respondPrepareError(c, err, fmt.Sprintf("recipients[%d]: ", i))
+			return nil, false
+		}
+		messages[i] = &msg
+	}
+	if err := h.applySuppressions(ctx, messages); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to check suppressions",
+		})
+		return nil, false
+	}
+
+	return messages, true
+}
+
+// createBatch stores messages with create, leaving out duplicates found in
+// Redis before and by create itself. It returns the created messages and the
+// duplicates ordered by their index in messages, with their recipients masked
+// unless unmasked is set.
+func (h *Handler) createBatch(
+	ctx context.Context,
+	messages []*model.Message,
+	unmasked bool,
+	create func(pending []*model.Message) (map[int]int, error),
+) ([]*model.Message, []DuplicateMessageResponse, error) {
+	var duplicates []DuplicateMessageResponse
+	pending := make([]*model.Message, 0, len(messages))
+	// indexes maps positions in pending back to positions in messages.
+	indexes := make([]int, 0, len(messages))
+	for i, msg := range messages {
+		if existing, ok := h.dedup.Lookup(ctx, *msg); ok {
+			duplicates = append(duplicates, duplicateResponse(i, *msg, existing, unmasked))
+			continue
+		}
+		pending = append(pending, msg)
+		indexes = append(indexes, i)
+	}
+
+	skipped, err := create(pending)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	created := make([]*model.Message, 0, len(pending))
+	for i, msg := range pending {
+		if existing, ok := skipped[i]; ok {
+			duplicates = append(duplicates, duplicateResponse(indexes[i], *msg, existing, unmasked))
+			continue
+		}
+		h.dedup.Remember(ctx, *msg)
+		created = append(created, msg)
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		return *duplicates[i].Index < *duplicates[j].Index
+	})
+
+	return created, duplicates, nil
+}
+
+func duplicateResponse(index int, msg model.Message, existing int, unmasked bool) DuplicateMessageResponse {
+	resp := DuplicateMessageResponse{
+		Index:     &index,
+		To:        msg.To,
+		MessageID: existing,
+	}
+	if !unmasked {
+		resp.To = redact.Recipient(msg.To)
+	}
+	return resp
+}
+
+// requestError is a problem with the request that should be reported to the
+// client with status instead of as an internal error.
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// prepareMessage normalises msg's recipient and locale, validates its time zone
+// and checks that it has
+// exactly one of content and template, and that the content, or the template
+// rendered in the message's locale, is within the limit of its channel. With deduplication enabled it also
+// sets the dedup key.
+func (h *Handler) prepareMessage(ctx context.Context, msg *model.Message) error {
+	if msg.Channel == "" {
+		msg.Channel = model.DefaultChannel
+	}
+	to, err := h.recipients.Normalize(msg.Channel, msg.To)
+	if err != nil {
+		return &requestError{http.StatusBadRequest, "Invalid recipient: " + err.Error()}
+	}
+	msg.To = to
+
+	locale, err := templating.NormalizeLocale(msg.Locale)
+	if err != nil {
+		return &requestError{http.StatusBadRequest, err.Error()}
+	}
+	msg.Locale = locale
+
+	if msg.Timezone != "" {
+		if err := quiethours.ValidateTimezone(msg.Timezone); err != nil {
+			return &requestError{http.StatusBadRequest, err.Error()}
+		}
+	}
+
+	if (msg.Content == "") == (msg.TemplateID == nil) {
+		return &requestError{http.StatusBadRequest, "Exactly one of content and template_id is required"}
+	}
+	if msg.TemplateID == nil && msg.Variables != nil {
+		return &requestError{http.StatusBadRequest, "variables can only be used with template_id"}
+	}
+	if n, limit := utf8.RuneCountInString(msg.Content), msg.Channel.MaxContentLength(); n > limit {
+		return &requestError{http.StatusBadRequest, fmt.Sprintf("content is %d characters, the %s channel allows %d", n, msg.Channel, limit)}
+	}
+
+	if msg.TemplateID != nil {
+		_, err = h.renderer.Render(ctx, *msg)
+		switch {
+		case errors.Is(err, repository.ErrTemplateNotFound):
+			return &requestError{http.StatusNotFound, "Template not found"}
+		case errors.Is(err, templating.ErrRender):
+			return &requestError{http.StatusUnprocessableEntity, err.Error()}
+		case err != nil:
+			return err
+		}
+	}
+
+	if h.dedup.Enabled() {
+		msg.DedupKey = h.dedup.Key(*msg)
+	}
+	return nil
+}
+
+// applySuppressions marks messages to recipients suppressed by their tenant so
+// they are stored as suppressed instead of being queued. All messages belong
+// to the same tenant.
+func (h *Handler) applySuppressions(ctx context.Context, messages []*model.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	recipients := make([]string, len(messages))
+	for i, msg := range messages {
+		recipients[i] = msg.To
+	}
+
+	suppressions, err := h.suppressionRepo.FindSuppressions(ctx, messages[0].TenantID, recipients)
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range messages {
+		if suppression, ok := suppressions[msg.To]; ok {
+			reason := suppression.StatusReason()
+			msg.Status = model.StatusSuppressed
+			msg.StatusReason = &reason
+		}
+	}
+	return nil
+}
+
+func respondPrepareError(c *gin.Context, err error, prefix string) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.status, ErrorResponse{
+			Error: prefix + reqErr.message,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error: "Failed to validate message",
+	})
+}
+
+func mergeVariables(shared, own map[string]any) map[string]any {
+	if own == nil {
+		return shared
+	}
+	if shared == nil {
+		return own
+	}
+
+	merged := make(map[string]any, len(shared)+len(own))
+	for k, v := range shared {
+		merged[k] = v
+	}
+	for k, v := range own {
+		merged[k] = v
+	}
+	return merged
+}
+
 // ListSentMessages godoc
 // @Summary      Get list of sent messages
-// @Description  Retrieve all messages that have been sent
+// @Description  Retrieve all messages that have been sent. Recipients and content are masked, and variables
+// @Description  left out, unless the caller has the pii:read scope.
 // @Tags         messages
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}  ListSentMessagesResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
 // @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /messages/sent [get]
 func (h *Handler) ListSentMessages(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
-	messages, err := h.messageRepo.GetSentMessages(ctx)
+	messages, err := h.messageRepo.GetSentMessages(ctx, callerTenant(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to fetch sent messages",
@@ -28,22 +487,10 @@ func (h *Handler) ListSentMessages(c *gin.Context) {
 		return
 	}
 
+	unmasked := callerSeesPII(c)
 	messageResponses := make([]MessageResponse, len(messages))
 	for i, msg := range messages {
-		messageResponses[i] = MessageResponse{
-			ID:        msg.ID,
-			To:        msg.To,
-			Content:   msg.Content,
-			Status:    string(msg.Status),
-			CreatedAt: msg.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: msg.UpdatedAt.Format(time.RFC3339),
-		}
-		if msg.SentAt != nil {
-			messageResponses[i].SentAt = msg.SentAt.Format(time.RFC3339)
-		}
-		if msg.MessageID != nil {
-			messageResponses[i].MessageID = msg.MessageID.String()
-		}
+		messageResponses[i] = toMessageResponse(msg, unmasked)
 	}
 
 	c.JSON(http.StatusOK, ListSentMessagesResponse{
@@ -51,3 +498,214 @@ func (h *Handler) ListSentMessages(c *gin.Context) {
 		Total:    len(messageResponses),
 	})
 }
+
+// ListMessageAttempts godoc
+// @Summary      Get delivery attempts of a message
+// @Description  Retrieve every webhook delivery attempt recorded for a message, oldest first. The provider's
+// @Description  response body, which may echo the recipient or content, is masked unless the caller has the
+// @Description  pii:read scope.
+// @Tags         messages
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Message ID"
+// @Success      200  {object}  ListMessageAttemptsResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /messages/{id}/attempts [get]
+func (h *Handler) ListMessageAttempts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid message ID",
+		})
+		return
+	}
+
+	if _, err := h.messageRepo.GetMessageByID(ctx, callerTenant(c), id); err != nil {
+		if errors.Is(err, repository.ErrMessageNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Message not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch message",
+		})
+		return
+	}
+
+	attempts, err := h.attemptRepo.GetAttemptsByMessageID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch message attempts",
+		})
+		return
+	}
+
+	unmasked := callerSeesPII(c)
+	attemptResponses := make([]AttemptResponse, len(attempts))
+	for i, attempt := range attempts {
+		if attempt.ResponseBody != nil && !unmasked {
+			masked := redact.Content(*attempt.ResponseBody)
+			attempt.ResponseBody = &masked
+		}
+		attemptResponses[i] = AttemptResponse{
+			ID:           attempt.ID,
+			StartedAt:    attempt.StartedAt.Format(time.RFC3339Nano),
+			StatusCode:   attempt.StatusCode,
+			LatencyMs:    attempt.LatencyMs,
+			ResponseBody: attempt.ResponseBody,
+			Error:        attempt.Error,
+		}
+		if attempt.FinishedAt != nil {
+			attemptResponses[i].FinishedAt = attempt.FinishedAt.Format(time.RFC3339Nano)
+		}
+		if attempt.ProviderMessageID != nil {
+			attemptResponses[i].ProviderMessageID = attempt.ProviderMessageID.String()
+		}
+	}
+
+	c.JSON(http.StatusOK, ListMessageAttemptsResponse{
+		MessageID: id,
+		Attempts:  attemptResponses,
+		Total:     len(attemptResponses),
+	})
+}
+
+// ListMessageParts godoc
+// @Summary      Get the parts of a long message
+// @Description  Retrieve the parts recorded for a message whose content was sent as linked SMS segments.
+// @Description  The message is only sent once every part has been sent.
+// @Description  Part content is masked unless the caller has the pii:read scope.
+// @Tags         messages
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Message ID"
+// @Success      200  {object}  ListMessagePartsResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /messages/{id}/parts [get]
+func (h *Handler) ListMessageParts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid message ID",
+		})
+		return
+	}
+
+	if _, err := h.messageRepo.GetMessageByID(ctx, callerTenant(c), id); err != nil {
+		if errors.Is(err, repository.ErrMessageNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Message not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch message",
+		})
+		return
+	}
+
+	parts, err := h.attemptRepo.GetPartsByMessageID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch message parts",
+		})
+		return
+	}
+
+	unmasked := callerSeesPII(c)
+	partResponses := make([]PartResponse, len(parts))
+	for i, part := range parts {
+		partResponses[i] = PartResponse{
+			Number:  part.Number,
+			Total:   part.Total,
+			Content: part.Content,
+			Status:  string(part.Status),
+			Error:   part.Error,
+		}
+		if !unmasked {
+			partResponses[i].Content = redact.Content(part.Content)
+		}
+		if part.ProviderMessageID != nil {
+			partResponses[i].ProviderMessageID = part.ProviderMessageID.String()
+		}
+		if part.SentAt != nil {
+			partResponses[i].SentAt = part.SentAt.Format(time.RFC3339Nano)
+		}
+	}
+
+	c.JSON(http.StatusOK, ListMessagePartsResponse{
+		MessageID: id,
+		Parts:     partResponses,
+		Total:     len(partResponses),
+	})
+}
+
+// toMessageResponse converts msg, masking its recipient and replacing its
+// content and variables unless unmasked is set.
+func toMessageResponse(msg model.Message, unmasked bool) MessageResponse {
+	resp := MessageResponse{
+		ID:           msg.ID,
+		TenantID:     msg.TenantID,
+		To:           msg.To,
+		Channel:      string(msg.Channel),
+		Content:      msg.Content,
+		Status:       string(msg.Status),
+		StatusReason: msg.StatusReason,
+		Priority:     msg.Priority.String(),
+		TemplateID:   msg.TemplateID,
+		Variables:    msg.Variables,
+		Locale:       msg.Locale,
+		Timezone:     msg.Timezone,
+		Critical:     msg.Critical,
+		DedupKey:     msg.DedupKey,
+		CampaignID:   msg.CampaignID,
+		CreatedBy:    msg.CreatedBy,
+		CreatedAt:    msg.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:    msg.UpdatedAt.Format(time.RFC3339),
+	}
+	if msg.Content != "" && msg.Channel.Segmented() {
+		encoding, parts := sms.Split(msg.Content)
+		resp.Parts = len(parts)
+		resp.Encoding = string(encoding)
+	}
+	if msg.SendAfter != nil {
+		resp.SendAfter = msg.SendAfter.Format(time.RFC3339)
+	}
+	if msg.SentAt != nil {
+		resp.SentAt = msg.SentAt.Format(time.RFC3339)
+	}
+	if msg.MessageID != nil {
+		resp.MessageID = msg.MessageID.String()
+	}
+	if msg.DeliveredAt != nil {
+		resp.DeliveredAt = msg.DeliveredAt.Format(time.RFC3339)
+	}
+	if msg.ReadAt != nil {
+		resp.ReadAt = msg.ReadAt.Format(time.RFC3339)
+	}
+	if !unmasked {
+		resp.To = redact.Recipient(msg.To)
+		resp.Content = redact.Content(msg.Content)
+		resp.Variables = nil
+	}
+	return resp
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/metrics"
+)
+
+// unmatchedRoute labels requests that matched no route, so arbitrary paths
+// cannot create new series.
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count and duration of every request by route.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startedAt := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(startedAt))
+	}
+}
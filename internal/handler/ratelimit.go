@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/ratelimit"
+)
+
+// Rate limit headers set on every limited response.
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// RateLimitByIP limits requests per client IP. It runs before authentication,
+// so clients guessing keys are limited too.
+func RateLimitByIP(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, ok := limiter.AllowIP(c.Request.Context(), c.ClientIP())
+		if ok && !applyRateLimit(c, result) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitByCaller limits requests per authenticated caller, i.e. per API
+// key or token subject, and per tenant. It must run after Authenticate.
+func RateLimitByCaller(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, ok := limiter.AllowCaller(c.Request.Context(), callerTenant(c), callerSubject(c))
+		if ok && !applyRateLimit(c, result) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// applyRateLimit sets the rate limit headers and rejects the request with 429
+// if it is over the limit. It returns whether the request may continue.
+func applyRateLimit(c *gin.Context, result ratelimit.Result) bool {
+	c.Header(RateLimitLimitHeader, strconv.Itoa(result.Limit))
+	c.Header(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+	c.Header(RateLimitResetHeader, strconv.FormatInt(result.Reset.Unix(), 10))
+
+	if result.Allowed {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(result.RetryAfter(time.Now()).Seconds()))
+	c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{
+		Error: "Rate limit exceeded",
+	})
+	return false
+}
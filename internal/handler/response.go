@@ -1,26 +1,390 @@
 package handler
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// CreateMessageRequest needs either content or template_id, and either to or
+// contact_id. Templated messages are rendered with variables when they are sent.
+type CreateMessageRequest struct {
+	To string `json:"to,omitempty" binding:"required_without=ContactID,max=254"`
+	// ContactID sends to a contact, whose attributes templates see as .contact.
+	ContactID  *int           `json:"contact_id,omitempty"`
+	Channel    string         `json:"channel,omitempty" enums:"webhook,sms,email" default:"webhook"`
+	Content    string         `json:"content,omitempty" binding:"max=1600"`
+	TemplateID *int           `json:"template_id,omitempty"`
+	Variables  map[string]any `json:"variables,omitempty"`
+	Locale     string         `json:"locale,omitempty" example:"tr-TR"`
+	Timezone   string         `json:"timezone,omitempty" example:"Europe/Istanbul"`
+	Critical   bool           `json:"critical,omitempty"`
+	Priority   string         `json:"priority,omitempty" enums:"low,normal,high,urgent" default:"normal"`
+	// DedupKey replaces the content hash when deduplication is enabled.
+	DedupKey string `json:"dedup_key,omitempty" binding:"max=128"`
+}
+
+type CreateMessagesRequest struct {
+	Channel    string         `json:"channel,omitempty" enums:"webhook,sms,email" default:"webhook"`
+	Content    string         `json:"content,omitempty" binding:"max=1600"`
+	TemplateID *int           `json:"template_id,omitempty"`
+	Variables  map[string]any `json:"variables,omitempty"`
+	Priority   string         `json:"priority,omitempty" enums:"low,normal,high,urgent" default:"normal"`
+	Critical   bool           `json:"critical,omitempty"`
+	// Either recipients or segment is required.
+	Recipients []RecipientInput `json:"recipients,omitempty" binding:"max=1000,dive"`
+	Segment    *SegmentRequest  `json:"segment,omitempty"`
+}
+
+type RecipientInput struct {
+	To        string         `json:"to" binding:"required,max=254"`
+	Locale    string         `json:"locale,omitempty" example:"tr-TR"`
+	Timezone  string         `json:"timezone,omitempty" example:"Europe/Istanbul"`
+	Variables map[string]any `json:"variables,omitempty"`
+	DedupKey  string         `json:"dedup_key,omitempty" binding:"max=128"`
+}
+
+type CreateMessagesResponse struct {
+	Messages []MessageResponse `json:"messages"`
+	Total    int               `json:"total"`
+	// Duplicates lists recipients that were skipped by deduplication.
+	Duplicates []DuplicateMessageResponse `json:"duplicates,omitempty"`
+}
+
+type DuplicateMessageResponse struct {
+	Error string `json:"error,omitempty"`
+	// Index is the position in recipients, only set for batches.
+	Index *int   `json:"index,omitempty"`
+	To    string `json:"to"`
+	// MessageID is the earlier message, 0 if it was deleted since.
+	MessageID int `json:"message_id"`
+}
+
 type ListSentMessagesResponse struct {
 	Messages []MessageResponse `json:"messages"`
 	Total    int               `json:"total"`
 }
 
 type MessageResponse struct {
+	ID       int    `json:"id"`
+	TenantID string `json:"tenant_id"`
+	To       string `json:"to"`
+	Channel  string `json:"channel"`
+	Content  string `json:"content"`
+	// Parts is the number of segments content is sent in, with Encoding.
+	Parts        int            `json:"parts,omitempty"`
+	Encoding     string         `json:"encoding,omitempty" enums:"gsm7,ucs2"`
+	Status       string         `json:"status"`
+	StatusReason *string        `json:"status_reason,omitempty"`
+	Priority     string         `json:"priority"`
+	TemplateID   *int           `json:"template_id,omitempty"`
+	Variables    map[string]any `json:"variables,omitempty"`
+	Locale       string         `json:"locale,omitempty"`
+	Timezone     string         `json:"timezone,omitempty"`
+	Critical     bool           `json:"critical"`
+	SendAfter    string         `json:"send_after,omitempty"`
+	DedupKey     string         `json:"dedup_key,omitempty"`
+	CampaignID   *int           `json:"campaign_id,omitempty"`
+	CreatedBy    string         `json:"created_by,omitempty"`
+	SentAt       string         `json:"sent_at,omitempty"`
+	MessageID    string         `json:"message_id,omitempty"`
+	DeliveredAt  string         `json:"delivered_at,omitempty"`
+	ReadAt       string         `json:"read_at,omitempty"`
+	CreatedAt    string         `json:"created_at"`
+	UpdatedAt    string         `json:"updated_at"`
+}
+
+type ListMessageAttemptsResponse struct {
+	MessageID int               `json:"message_id"`
+	Attempts  []AttemptResponse `json:"attempts"`
+	Total     int               `json:"total"`
+}
+
+type AttemptResponse struct {
+	ID                int     `json:"id"`
+	StartedAt         string  `json:"started_at"`
+	FinishedAt        string  `json:"finished_at,omitempty"`
+	StatusCode        *int    `json:"status_code,omitempty"`
+	LatencyMs         *int64  `json:"latency_ms,omitempty"`
+	ResponseBody      *string `json:"response_body,omitempty"`
+	ProviderMessageID string  `json:"provider_message_id,omitempty"`
+	Error             *string `json:"error,omitempty"`
+}
+
+type ListMessagePartsResponse struct {
+	MessageID int            `json:"message_id"`
+	Parts     []PartResponse `json:"parts"`
+	Total     int            `json:"total"`
+}
+
+type PartResponse struct {
+	Number            int     `json:"number"`
+	Total             int     `json:"total"`
+	Content           string  `json:"content"`
+	Status            string  `json:"status" enums:"sent,failed"`
+	ProviderMessageID string  `json:"provider_message_id,omitempty"`
+	Error             *string `json:"error,omitempty"`
+	SentAt            string  `json:"sent_at,omitempty"`
+}
+
+type TemplateRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	Body string `json:"body" binding:"required"`
+}
+
+type TemplateResponse struct {
 	ID        int    `json:"id"`
-	To        string `json:"to"`
-	Content   string `json:"content"`
-	Status    string `json:"status"`
-	SentAt    string `json:"sent_at,omitempty"`
-	MessageID string `json:"message_id,omitempty"`
+	Name      string `json:"name"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type ListTemplatesResponse struct {
+	Templates []TemplateResponse `json:"templates"`
+	Total     int                `json:"total"`
+}
+
+type TemplateVariantRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+type TemplateVariantResponse struct {
+	Locale    string `json:"locale"`
+	Body      string `json:"body"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
 
+type ListTemplateVariantsResponse struct {
+	TemplateID int                       `json:"template_id"`
+	Variants   []TemplateVariantResponse `json:"variants"`
+	Total      int                       `json:"total"`
+}
+
+type SuppressionRequest struct {
+	Recipient string `json:"recipient" binding:"required,max=254"`
+	Reason    string `json:"reason,omitempty" binding:"max=255" example:"unsubscribed"`
+}
+
+type ImportSuppressionsRequest struct {
+	// Reason is used for entries without their own reason.
+	Reason       string               `json:"reason,omitempty" binding:"max=255"`
+	Suppressions []SuppressionRequest `json:"suppressions" binding:"required,min=1,max=10000,dive"`
+}
+
+type ImportSuppressionsResponse struct {
+	Imported int `json:"imported"`
+}
+
+type SuppressionResponse struct {
+	Recipient string `json:"recipient"`
+	Reason    string `json:"reason"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ListSuppressionsResponse struct {
+	Suppressions []SuppressionResponse `json:"suppressions"`
+	Total        int                   `json:"total"`
+}
+
+type CreateCampaignRequest struct {
+	Name       string         `json:"name" binding:"required,max=100"`
+	TemplateID *int           `json:"template_id" binding:"required"`
+	Channel    string         `json:"channel,omitempty" enums:"webhook,sms,email" default:"webhook"`
+	Variables  map[string]any `json:"variables,omitempty"`
+	Priority   string         `json:"priority,omitempty" enums:"low,normal,high,urgent" default:"normal"`
+	Critical   bool           `json:"critical,omitempty"`
+	// Either recipients or segment is required.
+	Recipients []RecipientInput `json:"recipients,omitempty" binding:"max=10000,dive"`
+	Segment    *SegmentRequest  `json:"segment,omitempty"`
+}
+
+type CampaignResponse struct {
+	ID         int                   `json:"id"`
+	TenantID   string                `json:"tenant_id"`
+	Name       string                `json:"name"`
+	TemplateID int                   `json:"template_id"`
+	Channel    string                `json:"channel"`
+	Priority   string                `json:"priority"`
+	Status     string                `json:"status" enums:"running,paused,cancelled"`
+	Stats      CampaignStatsResponse `json:"stats"`
+	CreatedAt  string                `json:"created_at"`
+	UpdatedAt  string                `json:"updated_at"`
+	// Duplicates lists recipients skipped by deduplication, only on creation.
+	Duplicates []DuplicateMessageResponse `json:"duplicates,omitempty"`
+}
+
+type CampaignStatsResponse struct {
+	Total       int            `json:"total"`
+	ByStatus    map[string]int `json:"by_status"`
+	Sent        int            `json:"sent"`
+	FirstSentAt string         `json:"first_sent_at,omitempty"`
+	LastSentAt  string         `json:"last_sent_at,omitempty"`
+	// PerMinute is the average send rate between the first and last send.
+	PerMinute    float64 `json:"per_minute"`
+	SentLastHour int     `json:"sent_last_hour"`
+}
+
+type ListCampaignsResponse struct {
+	Campaigns []CampaignResponse `json:"campaigns"`
+	Total     int                `json:"total"`
+}
+
+// SegmentRequest selects contacts by tag. At least one list is required.
+type SegmentRequest struct {
+	// Tags must all be present.
+	Tags []string `json:"tags,omitempty" example:"vip"`
+	// AnyTags needs at least one to be present.
+	AnyTags []string `json:"any_tags,omitempty"`
+	// ExcludeTags must all be absent.
+	ExcludeTags []string `json:"exclude_tags,omitempty" example:"churned"`
+}
+
+type ContactRequest struct {
+	Recipient  string         `json:"recipient" binding:"required,max=254"`
+	Name       string         `json:"name,omitempty" binding:"max=255"`
+	Locale     string         `json:"locale,omitempty" example:"tr-TR"`
+	Timezone   string         `json:"timezone,omitempty" example:"Europe/Istanbul"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Tags       []string       `json:"tags,omitempty" binding:"max=50,dive,required,max=50"`
+}
+
+type ImportContactsRequest struct {
+	Contacts []ContactRequest `json:"contacts" binding:"required,min=1,max=10000,dive"`
+}
+
+type ImportContactsResponse struct {
+	Imported int `json:"imported"`
+}
+
+type ContactResponse struct {
+	ID         int            `json:"id"`
+	Recipient  string         `json:"recipient"`
+	Name       string         `json:"name"`
+	Locale     string         `json:"locale,omitempty"`
+	Timezone   string         `json:"timezone,omitempty"`
+	Attributes map[string]any `json:"attributes"`
+	Tags       []string       `json:"tags"`
+	CreatedAt  string         `json:"created_at"`
+	UpdatedAt  string         `json:"updated_at"`
+}
+
+type ListContactsResponse struct {
+	Contacts []ContactResponse `json:"contacts"`
+	Total    int               `json:"total"`
+}
+
+type APIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1" enums:"messages:read,messages:write,scheduler:admin,keys:admin,tenants:admin,audit:read,pii:read"`
+	// TenantID defaults to the caller's tenant.
+	TenantID string `json:"tenant_id,omitempty" binding:"max=64"`
+}
+
+type APIKeyResponse struct {
+	ID         int      `json:"id"`
+	TenantID   string   `json:"tenant_id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix" example:"cg_1a2b3c4d"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+}
+
+type TenantRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// WebhookURL replaces WEBHOOK_URL for the tenant's messages.
+	WebhookURL string `json:"webhook_url,omitempty" binding:"omitempty,url"`
+	// WebhookAuthKey is write-only; leave it empty to keep the stored key.
+	WebhookAuthKey string `json:"webhook_auth_key,omitempty"`
+	BatchLimit     *int   `json:"batch_limit,omitempty" binding:"omitempty,min=1"`
+	// RateLimit caps the requests of all the tenant's callers per window and
+	// RateLimitPerKey those of each caller; 0 disables the limit.
+	RateLimit       *int `json:"rate_limit,omitempty" binding:"omitempty,min=0"`
+	RateLimitPerKey *int `json:"rate_limit_per_key,omitempty" binding:"omitempty,min=0"`
+}
+
+type TenantResponse struct {
+	ID         string `json:"id" example:"billing"`
+	Name       string `json:"name"`
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// HasWebhookAuthKey tells whether a key is stored, without returning it.
+	HasWebhookAuthKey bool   `json:"has_webhook_auth_key"`
+	BatchLimit        *int   `json:"batch_limit,omitempty"`
+	RateLimit         *int   `json:"rate_limit,omitempty"`
+	RateLimitPerKey   *int   `json:"rate_limit_per_key,omitempty"`
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"updated_at"`
+}
+
+type ListTenantsResponse struct {
+	Tenants []TenantResponse `json:"tenants"`
+	Total   int              `json:"total"`
+}
+
+type AuditEntryResponse struct {
+	ID         int64           `json:"id"`
+	TenantID   string          `json:"tenant_id"`
+	Actor      string          `json:"actor" example:"api-key:3"`
+	Action     string          `json:"action" example:"scheduler.stop"`
+	TargetType string          `json:"target_type" example:"scheduler"`
+	TargetID   string          `json:"target_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+type ListAuditEntriesResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+	Total   int                  `json:"total"`
+}
+
+type RetentionRunResponse struct {
+	ID   int64  `json:"id"`
+	Mode string `json:"mode" example:"archive"`
+	// Purged counts the messages archived or deleted, by status.
+	Purged map[string]int `json:"purged"`
+	Total  int            `json:"total"`
+	// Error is set when the run stopped early.
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at"`
+}
+
+type ListRetentionRunsResponse struct {
+	Runs  []RetentionRunResponse `json:"runs"`
+	Total int                    `json:"total"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	// Key is only returned when the key is issued.
+	Key string `json:"key"`
+}
+
+type ListAPIKeysResponse struct {
+	Keys  []APIKeyResponse `json:"keys"`
+	Total int              `json:"total"`
+}
+
 type ToggleSchedulerResponse struct {
 	Message string `json:"message"`
 	Status  string `json:"status"`
 }
 
+type DeliveryReceiptRequest struct {
+	MessageID string     `json:"messageId" binding:"required"`
+	Status    string     `json:"status" binding:"required"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+type DeliveryReceiptResponse struct {
+	ID      int    `json:"id"`
+	Status  string `json:"status"`
+	Applied bool   `json:"applied"`
+}
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
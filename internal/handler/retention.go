@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+// ListRetentionRuns godoc
+// @Summary      List retention runs
+// @Description  Retrieve the reports of past retention runs, newest first: how many messages of each status were
+// @Description  archived or deleted. Runs cover every tenant.
+// @Tags         retention
+// @Accept       json
+// @Produce      json
+// @Param        limit   query     int  false  "Page size (max 1000)"  default(100)
+// @Param        offset  query     int  false  "Offset"                default(0)
+// @Success      200     {object}  ListRetentionRunsResponse
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      403     {object}  ErrorResponse
+// @Failure      429     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /retention/runs [get]
+func (h *Handler) ListRetentionRuns(c *gin.Context) {
+	limit, offset, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	runs, err := h.retentionRepo.ListRetentionRuns(c.Request.Context(), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch retention runs",
+		})
+		return
+	}
+
+	runResponses := make([]RetentionRunResponse, len(runs))
+	for i, run := range runs {
+		runResponses[i] = toRetentionRunResponse(run)
+	}
+
+	c.JSON(http.StatusOK, ListRetentionRunsResponse{
+		Runs:  runResponses,
+		Total: len(runResponses),
+	})
+}
+
+func toRetentionRunResponse(run model.RetentionRun) RetentionRunResponse {
+	purged := make(map[string]int, len(run.Purged))
+	for status, count := range run.Purged {
+		purged[string(status)] = count
+	}
+
+	return RetentionRunResponse{
+		ID:         run.ID,
+		Mode:       string(run.Mode),
+		Purged:     purged,
+		Total:      run.Total,
+		Error:      run.Error,
+		StartedAt:  run.StartedAt.Format(time.RFC3339),
+		FinishedAt: run.FinishedAt.Format(time.RFC3339),
+	}
+}
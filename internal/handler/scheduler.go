@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
 )
 
 // ToggleScheduler godoc
@@ -13,13 +15,19 @@ import (
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}  ToggleSchedulerResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
 // @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /scheduler/toggle [post]
 func (h *Handler) ToggleScheduler(c *gin.Context) {
 	isRunning := h.scheduler.IsRunning()
 
 	if isRunning {
 		h.scheduler.Stop()
+		h.audit(c, model.AuditSchedulerStop, nil, schedulerState(true), schedulerState(false))
 		c.JSON(http.StatusOK, ToggleSchedulerResponse{
 			Message: "Scheduler stopped",
 			Status:  "stopped",
@@ -31,9 +39,15 @@ func (h *Handler) ToggleScheduler(c *gin.Context) {
 			})
 			return
 		}
+		h.audit(c, model.AuditSchedulerStart, nil, schedulerState(false), schedulerState(true))
 		c.JSON(http.StatusOK, ToggleSchedulerResponse{
 			Message: "Scheduler started",
 			Status:  "running",
 		})
 	}
 }
+
+// schedulerState is the audited state of the scheduler.
+func schedulerState(running bool) map[string]bool {
+	return map[string]bool{"running": running}
+}
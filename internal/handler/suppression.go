@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/redact"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+)
+
+// CreateSuppression godoc
+// @Summary      Suppress a recipient
+// @Description  Stop messaging a recipient for the caller's tenant. Queued and new messages of the tenant to it
+// @Description  are marked suppressed instead of sent. The recipient is masked in the response unless the
+// @Description  caller has the pii:read scope.
+// @Tags         suppressions
+// @Accept       json
+// @Produce      json
+// @Param        suppression  body      SuppressionRequest  true  "Suppression"
+// @Success      201          {object}  SuppressionResponse
+// @Failure      400          {object}  ErrorResponse
+// @Failure      401          {object}  ErrorResponse
+// @Failure      403          {object}  ErrorResponse
+// @Failure      429          {object}  ErrorResponse
+// @Failure      500          {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /suppressions [post]
+func (h *Handler) CreateSuppression(c *gin.Context) {
+	var req SuppressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid suppression payload: " + err.Error(),
+		})
+		return
+	}
+
+	suppression, err := h.newSuppression(c, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	before, err := h.suppressionRepo.GetSuppression(c.Request.Context(), suppression.TenantID, suppression.Recipient)
+	if err != nil && !errors.Is(err, repository.ErrSuppressionNotFound) {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch suppression",
+		})
+		return
+	}
+
+	if err := h.suppressionRepo.AddSuppression(c.Request.Context(), suppression); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to add suppression",
+		})
+		return
+	}
+	h.audit(c, model.AuditSuppressionCreate, suppression.Recipient, before, suppression)
+
+	c.JSON(http.StatusCreated, toSuppressionResponse(*suppression, callerSeesPII(c)))
+}
+
+// ImportSuppressions godoc
+// @Summary      Bulk import suppressions
+// @Description  Suppress many recipients at once. Either every entry is imported or none is.
+// @Tags         suppressions
+// @Accept       json
+// @Produce      json
+// @Param        suppressions  body      ImportSuppressionsRequest  true  "Suppressions"
+// @Success      200           {object}  ImportSuppressionsResponse
+// @Failure      400           {object}  ErrorResponse
+// @Failure      401           {object}  ErrorResponse
+// @Failure      403           {object}  ErrorResponse
+// @Failure      429           {object}  ErrorResponse
+// @Failure      500           {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /suppressions/import [post]
+func (h *Handler) ImportSuppressions(c *gin.Context) {
+	var req ImportSuppressionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid suppression import payload: " + err.Error(),
+		})
+		return
+	}
+
+	suppressions := make([]*model.Suppression, len(req.Suppressions))
+	for i, entry := range req.Suppressions {
+		if entry.Reason == "" {
+			entry.Reason = req.Reason
+		}
+		suppression, err := h.newSuppression(c, entry)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: fmt.Sprintf("suppressions[%d]: %v", i, err),
+			})
+			return
+		}
+		suppressions[i] = suppression
+	}
+
+	if err := h.suppressionRepo.ImportSuppressions(c.Request.Context(), suppressions); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to import suppressions",
+		})
+		return
+	}
+	h.audit(c, model.AuditSuppressionImport, nil, nil, suppressions)
+
+	c.JSON(http.StatusOK, ImportSuppressionsResponse{
+		Imported: len(suppressions),
+	})
+}
+
+// ListSuppressions godoc
+// @Summary      List suppressions
+// @Description  Retrieve suppressed recipients, newest first. Recipients are masked unless the caller has the
+// @Description  pii:read scope.
+// @Tags         suppressions
+// @Accept       json
+// @Produce      json
+// @Param        limit   query     int  false  "Page size (max 1000)"  default(100)
+// @Param        offset  query     int  false  "Offset"                default(0)
+// @Success      200     {object}  ListSuppressionsResponse
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      403     {object}  ErrorResponse
+// @Failure      429     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /suppressions [get]
+func (h *Handler) ListSuppressions(c *gin.Context) {
+	limit, offset, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	suppressions, err := h.suppressionRepo.ListSuppressions(c.Request.Context(), callerTenant(c), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch suppressions",
+		})
+		return
+	}
+
+	unmasked := callerSeesPII(c)
+	suppressionResponses := make([]SuppressionResponse, len(suppressions))
+	for i, suppression := range suppressions {
+		suppressionResponses[i] = toSuppressionResponse(suppression, unmasked)
+	}
+
+	c.JSON(http.StatusOK, ListSuppressionsResponse{
+		Suppressions: suppressionResponses,
+		Total:        len(suppressionResponses),
+	})
+}
+
+// GetSuppression godoc
+// @Summary      Check whether a recipient is suppressed
+// @Description  The recipient is masked in the response unless the caller has the pii:read scope.
+// @Tags         suppressions
+// @Accept       json
+// @Produce      json
+// @Param        recipient  path      string  true  "Phone number or email address"
+// @Success      200        {object}  SuppressionResponse
+// @Failure      400        {object}  ErrorResponse
+// @Failure      401        {object}  ErrorResponse
+// @Failure      403        {object}  ErrorResponse
+// @Failure      404        {object}  ErrorResponse
+// @Failure      429        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /suppressions/{recipient} [get]
+func (h *Handler) GetSuppression(c *gin.Context) {
+	recipient, ok := h.recipientParam(c)
+	if !ok {
+		return
+	}
+
+	suppression, err := h.suppressionRepo.GetSuppression(c.Request.Context(), callerTenant(c), recipient)
+	if err != nil {
+		respondSuppressionError(c, err, "Failed to fetch suppression")
+		return
+	}
+
+	c.JSON(http.StatusOK, toSuppressionResponse(*suppression, callerSeesPII(c)))
+}
+
+// DeleteSuppression godoc
+// @Summary      Remove a suppression
+// @Description  Allow messaging the recipient again. Messages already marked suppressed are not resent.
+// @Tags         suppressions
+// @Accept       json
+// @Produce      json
+// @Param        recipient  path  string  true  "Phone number or email address"
+// @Success      204
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /suppressions/{recipient} [delete]
+func (h *Handler) DeleteSuppression(c *gin.Context) {
+	recipient, ok := h.recipientParam(c)
+	if !ok {
+		return
+	}
+
+	before, err := h.suppressionRepo.GetSuppression(c.Request.Context(), callerTenant(c), recipient)
+	if err != nil {
+		respondSuppressionError(c, err, "Failed to fetch suppression")
+		return
+	}
+
+	if err := h.suppressionRepo.DeleteSuppression(c.Request.Context(), callerTenant(c), recipient); err != nil {
+		respondSuppressionError(c, err, "Failed to delete suppression")
+		return
+	}
+	h.audit(c, model.AuditSuppressionDelete, recipient, before, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) newSuppression(c *gin.Context, req SuppressionRequest) (*model.Suppression, error) {
+	recipient, err := h.recipients.NormalizeAny(req.Recipient)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid recipient: %w", err)
+	}
+	return &model.Suppression{TenantID: callerTenant(c), Recipient: recipient, Reason: req.Reason}, nil
+}
+
+func (h *Handler) recipientParam(c *gin.Context) (string, bool) {
+	recipient, err := h.recipients.NormalizeAny(c.Param("recipient"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid recipient: " + err.Error(),
+		})
+		return "", false
+	}
+	return recipient, true
+}
+
+func respondSuppressionError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, repository.ErrSuppressionNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Suppression not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fallback})
+}
+
+// toSuppressionResponse converts suppression, masking its recipient unless
+// unmasked is set.
+func toSuppressionResponse(suppression model.Suppression, unmasked bool) SuppressionResponse {
+	resp := SuppressionResponse{
+		Recipient: suppression.Recipient,
+		Reason:    suppression.Reason,
+		CreatedAt: suppression.CreatedAt.Format(time.RFC3339),
+	}
+	if !unmasked {
+		resp.Recipient = redact.Recipient(suppression.Recipient)
+	}
+	return resp
+}
@@ -0,0 +1,435 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+	"github.com/kubilayrn/ChronoGo/internal/templating"
+)
+
+// CreateTemplate godoc
+// @Summary      Create a template
+// @Description  Create a message template. The body uses Go text/template syntax, e.g. "Hello {{.name}}".
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Param        template  body      TemplateRequest  true  "Template"
+// @Success      201       {object}  TemplateResponse
+// @Failure      400       {object}  ErrorResponse
+// @Failure      401       {object}  ErrorResponse
+// @Failure      403       {object}  ErrorResponse
+// @Failure      409       {object}  ErrorResponse
+// @Failure      429       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /templates [post]
+func (h *Handler) CreateTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tmpl, ok := bindTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := h.templateRepo.CreateTemplate(ctx, tmpl); err != nil {
+		respondTemplateError(c, err, "Failed to create template")
+		return
+	}
+	h.audit(c, model.AuditTemplateCreate, tmpl.ID, nil, tmpl)
+
+	c.JSON(http.StatusCreated, toTemplateResponse(*tmpl))
+}
+
+// ListTemplates godoc
+// @Summary      List templates
+// @Description  Retrieve all message templates ordered by name
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  ListTemplatesResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /templates [get]
+func (h *Handler) ListTemplates(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	templates, err := h.templateRepo.ListTemplates(ctx, callerTenant(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch templates",
+		})
+		return
+	}
+
+	templateResponses := make([]TemplateResponse, len(templates))
+	for i, tmpl := range templates {
+		templateResponses[i] = toTemplateResponse(tmpl)
+	}
+
+	c.JSON(http.StatusOK, ListTemplatesResponse{
+		Templates: templateResponses,
+		Total:     len(templateResponses),
+	})
+}
+
+// GetTemplate godoc
+// @Summary      Get a template
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Template ID"
+// @Success      200  {object}  TemplateResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /templates/{id} [get]
+func (h *Handler) GetTemplate(c *gin.Context) {
+	id, ok := templateIDParam(c)
+	if !ok {
+		return
+	}
+
+	tmpl, err := h.templateRepo.GetTemplate(c.Request.Context(), callerTenant(c), id)
+	if err != nil {
+		respondTemplateError(c, err, "Failed to fetch template")
+		return
+	}
+
+	c.JSON(http.StatusOK, toTemplateResponse(*tmpl))
+}
+
+// UpdateTemplate godoc
+// @Summary      Update a template
+// @Description  Replace a template's name and body. Unsent messages use the new body.
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int              true  "Template ID"
+// @Param        template  body      TemplateRequest  true  "Template"
+// @Success      200       {object}  TemplateResponse
+// @Failure      400       {object}  ErrorResponse
+// @Failure      401       {object}  ErrorResponse
+// @Failure      403       {object}  ErrorResponse
+// @Failure      404       {object}  ErrorResponse
+// @Failure      409       {object}  ErrorResponse
+// @Failure      429       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /templates/{id} [put]
+func (h *Handler) UpdateTemplate(c *gin.Context) {
+	id, ok := templateIDParam(c)
+	if !ok {
+		return
+	}
+
+	tmpl, ok := bindTemplate(c)
+	if !ok {
+		return
+	}
+	tmpl.ID = id
+
+	before, err := h.templateRepo.GetTemplate(c.Request.Context(), callerTenant(c), id)
+	if err != nil {
+		respondTemplateError(c, err, "Failed to fetch template")
+		return
+	}
+
+	if err := h.templateRepo.UpdateTemplate(c.Request.Context(), tmpl); err != nil {
+		respondTemplateError(c, err, "Failed to update template")
+		return
+	}
+	h.audit(c, model.AuditTemplateUpdate, id, before, tmpl)
+
+	c.JSON(http.StatusOK, toTemplateResponse(*tmpl))
+}
+
+// DeleteTemplate godoc
+// @Summary      Delete a template
+// @Description  Delete a template that no message references
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Param        id   path  int  true  "Template ID"
+// @Success      204
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /templates/{id} [delete]
+func (h *Handler) DeleteTemplate(c *gin.Context) {
+	id, ok := templateIDParam(c)
+	if !ok {
+		return
+	}
+
+	before, err := h.templateRepo.GetTemplate(c.Request.Context(), callerTenant(c), id)
+	if err != nil {
+		respondTemplateError(c, err, "Failed to fetch template")
+		return
+	}
+
+	if err := h.templateRepo.DeleteTemplate(c.Request.Context(), callerTenant(c), id); err != nil {
+		respondTemplateError(c, err, "Failed to delete template")
+		return
+	}
+	h.audit(c, model.AuditTemplateDelete, id, before, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListTemplateVariants godoc
+// @Summary      List locale variants of a template
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Template ID"
+// @Success      200  {object}  ListTemplateVariantsResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /templates/{id}/variants [get]
+func (h *Handler) ListTemplateVariants(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, ok := templateIDParam(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.templateRepo.GetTemplate(ctx, callerTenant(c), id); err != nil {
+		respondTemplateError(c, err, "Failed to fetch template")
+		return
+	}
+
+	variants, err := h.templateRepo.ListVariants(ctx, callerTenant(c), id)
+	if err != nil {
+		respondTemplateError(c, err, "Failed to fetch template variants")
+		return
+	}
+
+	variantResponses := make([]TemplateVariantResponse, len(variants))
+	for i, variant := range variants {
+		variantResponses[i] = toTemplateVariantResponse(variant)
+	}
+
+	c.JSON(http.StatusOK, ListTemplateVariantsResponse{
+		TemplateID: id,
+		Variants:   variantResponses,
+		Total:      len(variantResponses),
+	})
+}
+
+// PutTemplateVariant godoc
+// @Summary      Create or replace a locale variant of a template
+// @Description  Messages in this locale, or a more specific one such as tr-TR for tr, use this body
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                     true  "Template ID"
+// @Param        locale   path      string                  true  "Locale, e.g. tr-TR"
+// @Param        variant  body      TemplateVariantRequest  true  "Variant"
+// @Success      200      {object}  TemplateVariantResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      429      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /templates/{id}/variants/{locale} [put]
+func (h *Handler) PutTemplateVariant(c *gin.Context) {
+	id, ok := templateIDParam(c)
+	if !ok {
+		return
+	}
+
+	locale, ok := localeParam(c)
+	if !ok {
+		return
+	}
+
+	var req TemplateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid template variant payload: " + err.Error(),
+		})
+		return
+	}
+
+	if _, err := templating.Parse(req.Body); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	before, err := h.templateRepo.GetVariant(c.Request.Context(), callerTenant(c), id, locale)
+	if err != nil && !errors.Is(err, repository.ErrVariantNotFound) {
+		respondTemplateError(c, err, "Failed to fetch template variant")
+		return
+	}
+
+	variant := model.TemplateVariant{
+		TemplateID: id,
+		Locale:     locale,
+		Body:       req.Body,
+	}
+	if err := h.templateRepo.UpsertVariant(c.Request.Context(), callerTenant(c), &variant); err != nil {
+		respondTemplateError(c, err, "Failed to save template variant")
+		return
+	}
+	h.audit(c, model.AuditVariantPut, variantTarget(id, locale), before, variant)
+
+	c.JSON(http.StatusOK, toTemplateVariantResponse(variant))
+}
+
+// DeleteTemplateVariant godoc
+// @Summary      Delete a locale variant of a template
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Param        id      path  int     true  "Template ID"
+// @Param        locale  path  string  true  "Locale, e.g. tr-TR"
+// @Success      204
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /templates/{id}/variants/{locale} [delete]
+func (h *Handler) DeleteTemplateVariant(c *gin.Context) {
+	id, ok := templateIDParam(c)
+	if !ok {
+		return
+	}
+
+	locale, ok := localeParam(c)
+	if !ok {
+		return
+	}
+
+	before, err := h.templateRepo.GetVariant(c.Request.Context(), callerTenant(c), id, locale)
+	if err != nil {
+		respondTemplateError(c, err, "Failed to fetch template variant")
+		return
+	}
+
+	if err := h.templateRepo.DeleteVariant(c.Request.Context(), callerTenant(c), id, locale); err != nil {
+		respondTemplateError(c, err, "Failed to delete template variant")
+		return
+	}
+	h.audit(c, model.AuditVariantDelete, variantTarget(id, locale), before, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// variantTarget identifies a variant in the audit log.
+func variantTarget(templateID int, locale string) string {
+	return strconv.Itoa(templateID) + "/" + locale
+}
+
+func bindTemplate(c *gin.Context) (*model.Template, bool) {
+	var req TemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid template payload: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	if _, err := templating.Parse(req.Body); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return nil, false
+	}
+
+	return &model.Template{TenantID: callerTenant(c), Name: req.Name, Body: req.Body}, true
+}
+
+func templateIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid template ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+func localeParam(c *gin.Context) (string, bool) {
+	locale, err := templating.NormalizeLocale(c.Param("locale"))
+	if err != nil || locale == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid locale",
+		})
+		return "", false
+	}
+	return locale, true
+}
+
+func respondTemplateError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Template not found"})
+	case errors.Is(err, repository.ErrVariantNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Template variant not found"})
+	case errors.Is(err, repository.ErrTemplateNameTaken):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Template name already exists"})
+	case errors.Is(err, repository.ErrTemplateInUse):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Template is referenced by messages or campaigns"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fallback})
+	}
+}
+
+func toTemplateResponse(tmpl model.Template) TemplateResponse {
+	return TemplateResponse{
+		ID:        tmpl.ID,
+		Name:      tmpl.Name,
+		Body:      tmpl.Body,
+		CreatedAt: tmpl.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: tmpl.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toTemplateVariantResponse(variant model.TemplateVariant) TemplateVariantResponse {
+	return TemplateVariantResponse{
+		Locale:    variant.Locale,
+		Body:      variant.Body,
+		CreatedAt: variant.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: variant.UpdatedAt.Format(time.RFC3339),
+	}
+}
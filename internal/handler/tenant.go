@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+)
+
+// PutTenant godoc
+// @Summary      Create or update a tenant
+// @Description  Create the tenant or replace its settings. A webhook URL sends the tenant's messages to its own
+// @Description  webhook; without one they go to WEBHOOK_URL. batch_limit caps the tenant's messages per scheduler batch.
+// @Description  rate_limit caps the requests of all the tenant's callers per rate limit window and rate_limit_per_key
+// @Description  those of each caller, replacing RATE_LIMIT_PER_TENANT and RATE_LIMIT_PER_KEY; 0 disables the limit.
+// @Description  Only callers of the default tenant can manage tenants.
+// @Tags         tenants
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string         true  "Tenant ID"
+// @Param        tenant  body      TenantRequest  true  "Tenant"
+// @Success      200     {object}  TenantResponse
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      403     {object}  ErrorResponse
+// @Failure      429     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /tenants/{id} [put]
+func (h *Handler) PutTenant(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := c.Param("id")
+	if err := model.ValidateTenantID(id); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	var req TenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid tenant payload: " + err.Error(),
+		})
+		return
+	}
+
+	existing, err := h.tenantRepo.GetTenant(ctx, id)
+	if err != nil && !errors.Is(err, repository.ErrTenantNotFound) {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch tenant",
+		})
+		return
+	}
+
+	if req.WebhookURL != "" && req.WebhookAuthKey == "" {
+		if existing == nil || existing.WebhookAuthKey == "" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "webhook_auth_key is required with webhook_url",
+			})
+			return
+		}
+	}
+
+	tenant := &model.Tenant{
+		ID:              id,
+		Name:            req.Name,
+		WebhookURL:      req.WebhookURL,
+		WebhookAuthKey:  req.WebhookAuthKey,
+		BatchLimit:      req.BatchLimit,
+		RateLimit:       req.RateLimit,
+		RateLimitPerKey: req.RateLimitPerKey,
+	}
+	if err := h.tenantRepo.UpsertTenant(ctx, tenant); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to save tenant",
+		})
+		return
+	}
+	h.audit(c, model.AuditTenantPut, id, existing, tenant)
+
+	c.JSON(http.StatusOK, toTenantResponse(*tenant))
+}
+
+// ListTenants godoc
+// @Summary      List tenants
+// @Description  Retrieve all tenants ordered by ID. Webhook auth keys are never returned.
+// @Tags         tenants
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  ListTenantsResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /tenants [get]
+func (h *Handler) ListTenants(c *gin.Context) {
+	tenants, err := h.tenantRepo.ListTenants(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch tenants",
+		})
+		return
+	}
+
+	tenantResponses := make([]TenantResponse, len(tenants))
+	for i, tenant := range tenants {
+		tenantResponses[i] = toTenantResponse(tenant)
+	}
+
+	c.JSON(http.StatusOK, ListTenantsResponse{
+		Tenants: tenantResponses,
+		Total:   len(tenantResponses),
+	})
+}
+
+// GetTenant godoc
+// @Summary      Get a tenant
+// @Description  Retrieve a tenant by ID
+// @Tags         tenants
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Tenant ID"
+// @Success      200  {object}  TenantResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /tenants/{id} [get]
+func (h *Handler) GetTenant(c *gin.Context) {
+	tenant, err := h.tenantRepo.GetTenant(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrTenantNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Tenant not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to fetch tenant",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toTenantResponse(*tenant))
+}
+
+func toTenantResponse(tenant model.Tenant) TenantResponse {
+	return TenantResponse{
+		ID:                tenant.ID,
+		Name:              tenant.Name,
+		WebhookURL:        tenant.WebhookURL,
+		HasWebhookAuthKey: tenant.WebhookAuthKey != "",
+		BatchLimit:        tenant.BatchLimit,
+		RateLimit:         tenant.RateLimit,
+		RateLimitPerKey:   tenant.RateLimitPerKey,
+		CreatedAt:         tenant.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:         tenant.UpdatedAt.Format(time.RFC3339),
+	}
+}
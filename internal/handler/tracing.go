@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+	"github.com/kubilayrn/ChronoGo/internal/tracing"
+)
+
+// Tracing starts a server span for every request, continuing the caller's
+// trace when it sends a W3C traceparent header. Health checks, metrics scrapes
+// and the Swagger UI are not traced.
+func Tracing() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		path := r.URL.Path
+		return path != "/health" && path != "/metrics" && !strings.HasPrefix(path, "/swagger/")
+	}))
+}
@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log formats accepted in LOG_FORMAT.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// NewLoggerFromEnv returns a logger writing to stdout in LOG_FORMAT (json by
+// default) at LOG_LEVEL (info by default). Records logged with a context carry
+// its request ID, trace and span IDs and any attributes added with With.
+// Recipients and content are redacted unless LOG_REDACTION is off.
+func NewLoggerFromEnv() *slog.Logger {
+	_ = godotenv.Load()
+
+	var level slog.Level
+	levelValue := os.Getenv("LOG_LEVEL")
+	invalidLevel := levelValue != "" && level.UnmarshalText([]byte(levelValue)) != nil
+	if invalidLevel {
+		level = slog.LevelInfo
+	}
+
+	formatValue := os.Getenv("LOG_FORMAT")
+	format := strings.ToLower(formatValue)
+	invalidFormat := format != "" && format != FormatJSON && format != FormatText
+	if invalidFormat {
+		format = FormatJSON
+	}
+
+	redactionValue := os.Getenv("LOG_REDACTION")
+	redaction := strings.ToLower(redactionValue)
+	invalidRedaction := redaction != "" && redaction != RedactionMask && redaction != RedactionOff
+	if invalidRedaction {
+		redaction = RedactionMask
+	}
+
+	logger := slog.New(newHandler(os.Stdout, format, level, redaction != RedactionOff))
+	if invalidLevel {
+		logger.Warn("Invalid value for LOG_LEVEL, using default info", "value", levelValue)
+	}
+	if invalidFormat {
+		logger.Warn("Invalid value for LOG_FORMAT, using default json", "value", formatValue)
+	}
+	if invalidRedaction {
+		logger.Warn("Invalid value for LOG_REDACTION, using default mask", "value", redactionValue)
+	}
+	return logger
+}
+
+func newHandler(w io.Writer, format string, level slog.Level, redact bool) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if redact {
+		opts.ReplaceAttr = redactAttr
+	}
+	if format == FormatText {
+		return &contextHandler{Handler: slog.NewTextHandler(w, opts)}
+	}
+	return &contextHandler{Handler: slog.NewJSONHandler(w, opts)}
+}
+
+type requestIDKey struct{}
+
+type attrsKey struct{}
+
+// WithRequestID returns a context whose log records carry id as request_id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored by WithRequestID, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// With returns a context whose log records carry attrs in addition to those
+// already added to ctx.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	combined := make([]slog.Attr, 0, len(existing)+len(attrs))
+	combined = append(combined, existing...)
+	combined = append(combined, attrs...)
+	return context.WithValue(ctx, attrsKey{}, combined)
+}
+
+// contextHandler adds the request ID, trace context and attributes stored on
+// the record's context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
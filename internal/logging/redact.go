@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"log/slog"
+
+	"github.com/kubilayrn/ChronoGo/internal/redact"
+)
+
+// Redaction modes accepted in LOG_REDACTION.
+const (
+	RedactionMask = "mask"
+	RedactionOff  = "off"
+)
+
+// Attributes under these keys hold personal data and are redacted wherever
+// they are logged, so callers log the raw values.
+var (
+	recipientKeys = map[string]bool{"to": true, "recipient": true}
+	contentKeys   = map[string]bool{"content": true, "response_body": true, "variables": true}
+)
+
+// redactAttr masks recipients and replaces content, keeping only its length.
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	switch {
+	case recipientKeys[a.Key]:
+		return slog.String(a.Key, redact.Recipient(a.Value.String()))
+	case contentKeys[a.Key]:
+		if a.Value.Kind() != slog.KindString {
+			return slog.String(a.Key, "[redacted]")
+		}
+		return slog.String(a.Key, redact.Content(a.Value.String()))
+	}
+	return a
+}
@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "chronogo"
+
+var (
+	messagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_sent_total",
+		Help:      "Messages accepted by the webhook, by response status code.",
+	}, []string{"status_code"})
+
+	messagesFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_failed_total",
+		Help:      "Messages the webhook did not accept, by response status code (0 when there was no response).",
+	}, []string{"status_code"})
+
+	webhookDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "webhook_request_duration_seconds",
+		Help:      "Duration of webhook calls, including failed ones.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	batchDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scheduler_batch_duration_seconds",
+		Help:      "Time taken to send a claimed batch of messages.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	})
+
+	schedulerRunning = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "scheduler_running",
+		Help:      "1 while the scheduler is running, 0 while it is stopped.",
+	})
+
+	messagesPurged = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_purged_total",
+		Help:      "Messages archived or deleted by the retention policy, by status and mode.",
+	}, []string{"status", "mode"})
+
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests, by method, route and response status code.",
+	}, []string{"method", "route", "status_code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// MessageSent counts a message accepted by the webhook.
+func MessageSent(statusCode int) {
+	messagesSent.WithLabelValues(strconv.Itoa(statusCode)).Inc()
+}
+
+// MessageFailed counts a message the webhook did not accept. statusCode is 0
+// when no response was received.
+func MessageFailed(statusCode int) {
+	messagesFailed.WithLabelValues(strconv.Itoa(statusCode)).Inc()
+}
+
+// ObserveWebhook records the duration of one webhook call.
+func ObserveWebhook(d time.Duration) {
+	webhookDuration.Observe(d.Seconds())
+}
+
+// ObserveBatch records the time taken to send one batch.
+func ObserveBatch(d time.Duration) {
+	batchDuration.Observe(d.Seconds())
+}
+
+// SetSchedulerRunning records whether the scheduler is running.
+func SetSchedulerRunning(running bool) {
+	if running {
+		schedulerRunning.Set(1)
+	} else {
+		schedulerRunning.Set(0)
+	}
+}
+
+// MessagesPurged counts messages of status archived or deleted by retention.
+func MessagesPurged(status, mode string, count int) {
+	if count > 0 {
+		messagesPurged.WithLabelValues(status, mode).Add(float64(count))
+	}
+}
+
+// ObserveHTTPRequest records one HTTP request. route is the matched route
+// pattern, so paths with IDs share a series.
+func ObserveHTTPRequest(method, route string, statusCode int, d time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(statusCode)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+// BacklogFunc reports how many messages are due but unsent, and how long the
+// oldest of them has been due.
+type BacklogFunc func(ctx context.Context) (count int, oldestAge time.Duration, err error)
+
+// backlogTimeout bounds the backlog query run on every scrape.
+const backlogTimeout = 5 * time.Second
+
+var (
+	backlogSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "unsent_messages"),
+		"Messages due to be sent that have not been claimed yet.",
+		nil, nil,
+	)
+	backlogAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "oldest_unsent_message_age_seconds"),
+		"How long the oldest unsent message has been due, 0 when there is none.",
+		nil, nil,
+	)
+)
+
+type backlogCollector struct {
+	backlog BacklogFunc
+}
+
+// RegisterBacklog exposes the unsent backlog, queried when metrics are scraped.
+func RegisterBacklog(backlog BacklogFunc) {
+	prometheus.MustRegister(&backlogCollector{backlog: backlog})
+}
+
+func (c *backlogCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- backlogSizeDesc
+	ch <- backlogAgeDesc
+}
+
+func (c *backlogCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), backlogTimeout)
+	defer cancel()
+
+	count, oldestAge, err := c.backlog(ctx)
+	if err != nil {
+		// Leaving the gauges out keeps the rest of the scrape usable.
+		slog.ErrorContext(ctx, "Failed to collect backlog metrics", "error", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(backlogSizeDesc, prometheus.GaugeValue, float64(count))
+	ch <- prometheus.MustNewConstMetric(backlogAgeDesc, prometheus.GaugeValue, oldestAge.Seconds())
+}
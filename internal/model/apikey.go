@@ -0,0 +1,56 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Scope is a permission granted to an API caller.
+type Scope string
+
+const (
+	ScopeMessagesRead   Scope = "messages:read"
+	ScopeMessagesWrite  Scope = "messages:write"
+	ScopeSchedulerAdmin Scope = "scheduler:admin"
+	// ScopeKeysAdmin allows issuing and revoking API keys.
+	ScopeKeysAdmin Scope = "keys:admin"
+	// ScopeTenantsAdmin allows managing tenants.
+	ScopeTenantsAdmin Scope = "tenants:admin"
+	// ScopeAuditRead allows reading the audit log.
+	ScopeAuditRead Scope = "audit:read"
+	// ScopePIIRead allows seeing recipients and message content unmasked.
+	ScopePIIRead Scope = "pii:read"
+)
+
+// Scopes lists every scope, e.g. for the bootstrap admin key.
+var Scopes = []Scope{ScopeMessagesRead, ScopeMessagesWrite, ScopeSchedulerAdmin, ScopeKeysAdmin, ScopeTenantsAdmin, ScopeAuditRead, ScopePIIRead}
+
+// OperatorOnly reports whether the scope acts on the whole deployment rather
+// than on one tenant's data, so it only takes effect for callers of the
+// default tenant.
+func (s Scope) OperatorOnly() bool {
+	return s == ScopeSchedulerAdmin || s == ScopeTenantsAdmin
+}
+
+// ParseScope returns the scope named s.
+func ParseScope(s string) (Scope, error) {
+	for _, scope := range Scopes {
+		if string(scope) == s {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("invalid scope %q", s)
+}
+
+// APIKey is an issued key. Only a hash of the key itself is stored; Prefix is
+// kept so a key can be recognised in listings.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	TenantID   string     `json:"tenant_id"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []Scope    `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MessageAttempt struct {
+	ID                int        `json:"id"`
+	MessageID         int        `json:"message_id"`
+	StartedAt         time.Time  `json:"started_at"`
+	FinishedAt        *time.Time `json:"finished_at,omitempty"`
+	StatusCode        *int       `json:"status_code,omitempty"`
+	LatencyMs         *int64     `json:"latency_ms,omitempty"`
+	ResponseBody      *string    `json:"response_body,omitempty"`
+	ProviderMessageID *uuid.UUID `json:"provider_message_id,omitempty"`
+	Error             *string    `json:"error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
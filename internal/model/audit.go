@@ -0,0 +1,74 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AuditAction names a state change, as "<target type>.<verb>".
+type AuditAction string
+
+const (
+	AuditSchedulerStart AuditAction = "scheduler.start"
+	AuditSchedulerStop  AuditAction = "scheduler.stop"
+
+	AuditMessageCreate      AuditAction = "message.create"
+	AuditMessageCreateBatch AuditAction = "message.create_batch"
+
+	AuditTemplateCreate AuditAction = "template.create"
+	AuditTemplateUpdate AuditAction = "template.update"
+	AuditTemplateDelete AuditAction = "template.delete"
+	AuditVariantPut     AuditAction = "template_variant.put"
+	AuditVariantDelete  AuditAction = "template_variant.delete"
+
+	AuditSuppressionCreate AuditAction = "suppression.create"
+	AuditSuppressionImport AuditAction = "suppression.import"
+	AuditSuppressionDelete AuditAction = "suppression.delete"
+
+	AuditContactCreate AuditAction = "contact.create"
+	AuditContactImport AuditAction = "contact.import"
+	AuditContactDelete AuditAction = "contact.delete"
+
+	AuditCampaignCreate AuditAction = "campaign.create"
+	AuditCampaignPause  AuditAction = "campaign.pause"
+	AuditCampaignResume AuditAction = "campaign.resume"
+	AuditCampaignCancel AuditAction = "campaign.cancel"
+
+	AuditAPIKeyCreate AuditAction = "api_key.create"
+	AuditAPIKeyRevoke AuditAction = "api_key.revoke"
+
+	AuditTenantPut AuditAction = "tenant.put"
+)
+
+// TargetType is the kind of object the action changes, e.g. "template".
+func (a AuditAction) TargetType() string {
+	targetType, _, _ := strings.Cut(string(a), ".")
+	return targetType
+}
+
+// AuditEntry records who changed what. Before and After hold the object as
+// returned by the API, or null where it did not exist.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	TenantID   string          `json:"tenant_id"`
+	Actor      string          `json:"actor"`
+	Action     AuditAction     `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditFilter selects audit entries. Empty fields match everything.
+type AuditFilter struct {
+	// TenantID is empty for callers that see every tenant's entries.
+	TenantID   string
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      *time.Time
+	Until      *time.Time
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+type CampaignStatus string
+
+const (
+	CampaignRunning   CampaignStatus = "running"
+	CampaignPaused    CampaignStatus = "paused"
+	CampaignCancelled CampaignStatus = "cancelled"
+)
+
+// Campaign owns the messages sent to one recipient list. Only messages of
+// running campaigns are claimed by the scheduler.
+type Campaign struct {
+	ID         int            `json:"id"`
+	TenantID   string         `json:"tenant_id"`
+	Name       string         `json:"name"`
+	TemplateID int            `json:"template_id"`
+	Channel    Channel        `json:"channel"`
+	Priority   Priority       `json:"priority"`
+	Status     CampaignStatus `json:"status"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// CampaignStats aggregates the messages of a campaign.
+type CampaignStats struct {
+	Total       int                   `json:"total"`
+	ByStatus    map[MessageStatus]int `json:"by_status"`
+	Sent        int                   `json:"sent"`
+	FirstSentAt *time.Time            `json:"first_sent_at,omitempty"`
+	LastSentAt  *time.Time            `json:"last_sent_at,omitempty"`
+	// SentLastHour is the number of messages sent in the last hour.
+	SentLastHour int `json:"sent_last_hour"`
+}
+
+// PerMinute is the average send rate between the first and the last send.
+func (s CampaignStats) PerMinute() float64 {
+	if s.FirstSentAt == nil || s.LastSentAt == nil {
+		return 0
+	}
+	minutes := s.LastSentAt.Sub(*s.FirstSentAt).Minutes()
+	if minutes < 1 {
+		return float64(s.Sent)
+	}
+	return float64(s.Sent) / minutes
+}
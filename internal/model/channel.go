@@ -0,0 +1,31 @@
+package model
+
+// Channel is how a message reaches its recipient. It decides how the "to"
+// value is validated.
+type Channel string
+
+const (
+	ChannelWebhook Channel = "webhook"
+	ChannelSMS     Channel = "sms"
+	ChannelEmail   Channel = "email"
+)
+
+// DefaultChannel is used for messages created without a channel.
+const DefaultChannel = ChannelWebhook
+
+// Segmented reports whether content on the channel is limited to SMS segments,
+// so long content has to be sent in parts. The default webhook channel always
+// posts content whole, as its provider expects.
+func (c Channel) Segmented() bool {
+	return c == ChannelSMS
+}
+
+// MaxContentLength is the longest content the channel accepts, in characters.
+// Channels that send content whole keep the limit they had before content
+// could be split.
+func (c Channel) MaxContentLength() int {
+	if c.Segmented() {
+		return MaxContentLength
+	}
+	return MaxUnsegmentedContentLength
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Contact is a reusable recipient with attributes that templates can use.
+type Contact struct {
+	ID         int            `json:"id"`
+	TenantID   string         `json:"tenant_id"`
+	Recipient  string         `json:"recipient"`
+	Name       string         `json:"name"`
+	Locale     string         `json:"locale,omitempty"`
+	Timezone   string         `json:"timezone,omitempty"`
+	Attributes map[string]any `json:"attributes"`
+	Tags       []string       `json:"tags"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// TemplateVariables is what templates see as .contact: the attributes plus
+// the contact's name and recipient.
+func (c Contact) TemplateVariables() map[string]any {
+	vars := make(map[string]any, len(c.Attributes)+2)
+	for k, v := range c.Attributes {
+		vars[k] = v
+	}
+	vars["name"] = c.Name
+	vars["recipient"] = c.Recipient
+	return vars
+}
+
+// Segment selects contacts by tag. An empty list does not restrict.
+type Segment struct {
+	// Tags must all be present.
+	Tags []string `json:"tags,omitempty"`
+	// AnyTags needs at least one to be present.
+	AnyTags []string `json:"any_tags,omitempty"`
+	// ExcludeTags must all be absent.
+	ExcludeTags []string `json:"exclude_tags,omitempty"`
+}
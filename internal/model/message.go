@@ -6,20 +6,72 @@ import (
 	"github.com/google/uuid"
 )
 
+// MaxContentLength is the maximum number of characters in a message's content.
+// Content longer than one SMS segment is sent in parts on segmented channels.
+const MaxContentLength = 1600
+
+// MaxUnsegmentedContentLength is the maximum on channels that send content
+// whole, see Channel.MaxContentLength.
+const MaxUnsegmentedContentLength = 320
+
 type MessageStatus string
 
 const (
-	StatusUnsent MessageStatus = "unsent"
-	StatusSent   MessageStatus = "sent"
+	StatusUnsent      MessageStatus = "unsent"
+	StatusProcessing  MessageStatus = "processing"
+	StatusSent        MessageStatus = "sent"
+	StatusFailed      MessageStatus = "failed"
+	StatusSuppressed  MessageStatus = "suppressed"
+	StatusDuplicate   MessageStatus = "duplicate"
+	StatusCancelled   MessageStatus = "cancelled"
+	StatusDelivered   MessageStatus = "delivered"
+	StatusUndelivered MessageStatus = "undelivered"
+	StatusRead        MessageStatus = "read"
 )
 
+// IsDeliveryReceipt reports whether the status can be reported by the provider
+// through a delivery receipt callback.
+func (s MessageStatus) IsDeliveryReceipt() bool {
+	switch s {
+	case StatusDelivered, StatusUndelivered, StatusRead:
+		return true
+	}
+	return false
+}
+
+// IsFinal reports whether the scheduler is done with a message in this
+// status. Only delivery receipts change it afterwards.
+func (s MessageStatus) IsFinal() bool {
+	switch s {
+	case StatusSent, StatusFailed, StatusSuppressed, StatusDuplicate, StatusCancelled,
+		StatusDelivered, StatusUndelivered, StatusRead:
+		return true
+	}
+	return false
+}
+
 type Message struct {
-	ID        int           `json:"id"`
-	To        string        `json:"to"`
-	Content   string        `json:"content"`
-	Status    MessageStatus `json:"status"`
-	SentAt    *time.Time    `json:"sent_at,omitempty"`
-	MessageID *uuid.UUID    `json:"message_id,omitempty"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
+	ID           int            `json:"id"`
+	TenantID     string         `json:"tenant_id"`
+	To           string         `json:"to"`
+	Channel      Channel        `json:"channel"`
+	Content      string         `json:"content"`
+	Status       MessageStatus  `json:"status"`
+	StatusReason *string        `json:"status_reason,omitempty"`
+	Priority     Priority       `json:"priority"`
+	TemplateID   *int           `json:"template_id,omitempty"`
+	Variables    map[string]any `json:"variables,omitempty"`
+	Locale       string         `json:"locale,omitempty"`
+	Timezone     string         `json:"timezone,omitempty"`
+	Critical     bool           `json:"critical"`
+	SendAfter    *time.Time     `json:"send_after,omitempty"`
+	DedupKey     string         `json:"dedup_key,omitempty"`
+	CampaignID   *int           `json:"campaign_id,omitempty"`
+	CreatedBy    string         `json:"created_by,omitempty"`
+	SentAt       *time.Time     `json:"sent_at,omitempty"`
+	MessageID    *uuid.UUID     `json:"message_id,omitempty"`
+	DeliveredAt  *time.Time     `json:"delivered_at,omitempty"`
+	ReadAt       *time.Time     `json:"read_at,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
 }
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PartStatus string
+
+const (
+	PartSent   PartStatus = "sent"
+	PartFailed PartStatus = "failed"
+)
+
+// MessagePart is one segment of a message that is too long to send at once.
+// Parts are recorded as they are sent so a retry only resends missing parts.
+type MessagePart struct {
+	MessageID         int        `json:"message_id"`
+	Number            int        `json:"number"`
+	Total             int        `json:"total"`
+	Content           string     `json:"content"`
+	Status            PartStatus `json:"status"`
+	ProviderMessageID *uuid.UUID `json:"provider_message_id,omitempty"`
+	Error             *string    `json:"error,omitempty"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+}
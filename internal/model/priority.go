@@ -0,0 +1,41 @@
+package model
+
+import "fmt"
+
+// Priority decides which unsent messages the scheduler picks first. Higher
+// values are sent earlier.
+type Priority int16
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLow:    "low",
+	PriorityNormal: "normal",
+	PriorityHigh:   "high",
+	PriorityUrgent: "urgent",
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("priority(%d)", int16(p))
+}
+
+// ParsePriority parses a priority name. An empty name is PriorityNormal.
+func ParsePriority(name string) (Priority, error) {
+	if name == "" {
+		return PriorityNormal, nil
+	}
+	for p, n := range priorityNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid priority %q, must be one of low, normal, high, urgent", name)
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// RetentionMode is what happens to messages past their retention period.
+type RetentionMode string
+
+const (
+	// RetentionArchive moves messages to the messages_archive table.
+	RetentionArchive RetentionMode = "archive"
+	// RetentionDelete deletes messages outright.
+	RetentionDelete RetentionMode = "delete"
+)
+
+// RetentionRun reports one pass of the retention policy.
+type RetentionRun struct {
+	ID   int64         `json:"id"`
+	Mode RetentionMode `json:"mode"`
+	// Purged counts the messages archived or deleted, by status.
+	Purged map[MessageStatus]int `json:"purged"`
+	Total  int                   `json:"total"`
+	// Error is set when the run stopped early. Batches purged before the
+	// error are still counted.
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
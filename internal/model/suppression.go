@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Suppression is a recipient that must not be messaged, e.g. because they
+// unsubscribed.
+type Suppression struct {
+	TenantID  string    `json:"tenant_id"`
+	Recipient string    `json:"recipient"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StatusReason is the status_reason recorded on messages to a suppressed recipient.
+func (s Suppression) StatusReason() string {
+	if s.Reason == "" {
+		return "recipient is suppressed"
+	}
+	return "recipient is suppressed: " + s.Reason
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+type Template struct {
+	ID        int       `json:"id"`
+	TenantID  string    `json:"tenant_id"`
+	Name      string    `json:"name"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TemplateVariant is a locale specific body of a template.
+type TemplateVariant struct {
+	ID         int       `json:"id"`
+	TemplateID int       `json:"template_id"`
+	Locale     string    `json:"locale"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// DefaultTenant owns data created before tenants were introduced and callers
+// without a tenant. Its callers operate the deployment: they manage tenants
+// and the API keys of every tenant.
+const DefaultTenant = "default"
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Tenant is a team sharing the deployment. It only sees its own messages,
+// campaigns, templates, contacts, suppressions and API keys, and may have its
+// own webhook and rate limits.
+type Tenant struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// WebhookAuthKey is never returned by the API.
+	WebhookAuthKey string `json:"-"`
+	// BatchLimit caps the tenant's messages in one scheduler batch.
+	BatchLimit *int `json:"batch_limit,omitempty"`
+	// RateLimit and RateLimitPerKey replace RATE_LIMIT_PER_TENANT and
+	// RATE_LIMIT_PER_KEY for the tenant's callers.
+	RateLimit       *int      `json:"rate_limit,omitempty"`
+	RateLimitPerKey *int      `json:"rate_limit_per_key,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ValidateTenantID checks that id is a lowercase slug usable as a tenant id.
+func ValidateTenantID(id string) error {
+	if !tenantIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid tenant id %q: use up to 64 lowercase letters, digits, '-' and '_'", id)
+	}
+	return nil
+}
@@ -2,44 +2,110 @@ package queue
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
+	"github.com/kubilayrn/ChronoGo/internal/dedup"
+	"github.com/kubilayrn/ChronoGo/internal/logging"
+	"github.com/kubilayrn/ChronoGo/internal/metrics"
 	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/quiethours"
 	"github.com/kubilayrn/ChronoGo/internal/redis"
 	"github.com/kubilayrn/ChronoGo/internal/repository"
 	"github.com/kubilayrn/ChronoGo/internal/sender"
+	"github.com/kubilayrn/ChronoGo/internal/sms"
+	"github.com/kubilayrn/ChronoGo/internal/templating"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/kubilayrn/ChronoGo/internal/queue")
+
+// DeliveryGuarantee decides what happens to a message whose outcome is unknown,
+// e.g. when the process dies between sending and recording the result.
+type DeliveryGuarantee string
+
+const (
+	// AtLeastOnce releases failed and abandoned claims for another attempt,
+	// so a message may be sent twice but is never lost.
+	AtLeastOnce DeliveryGuarantee = "at-least-once"
+	// AtMostOnce marks failed and abandoned claims as failed, so a message is
+	// never sent twice but may be lost.
+	AtMostOnce DeliveryGuarantee = "at-most-once"
 )
 
 type Scheduler struct {
-	mu            sync.RWMutex
-	isRunning     bool
-	stopChan      chan struct{}
-	ticker        *time.Ticker
-	repo          *repository.MessageRepository
-	webhookSender *sender.WebhookSender
-	ctx           context.Context
-	cancel        context.CancelFunc
-	interval      time.Duration
-	messageLimit  int
-}
-
-func NewScheduler(repo *repository.MessageRepository, webhookSender *sender.WebhookSender) *Scheduler {
+	mu              sync.RWMutex
+	isRunning       bool
+	stopChan        chan struct{}
+	ticker          *time.Ticker
+	repo            *repository.MessageRepository
+	outboxRepo      *repository.OutboxRepository
+	suppressionRepo *repository.SuppressionRepository
+	tenantRepo      *repository.TenantRepository
+	renderer        *templating.Renderer
+	dedup           *dedup.Deduplicator
+	webhookSender   *sender.WebhookSender
+	ctx             context.Context
+	cancel          context.CancelFunc
+	interval        time.Duration
+	messageLimit    int
+	guarantee       DeliveryGuarantee
+	claimTimeout    time.Duration
+	priorityAging   time.Duration
+	quietHours      *quiethours.Policy
+}
+
+func NewScheduler(
+	repo *repository.MessageRepository,
+	outboxRepo *repository.OutboxRepository,
+	suppressionRepo *repository.SuppressionRepository,
+	tenantRepo *repository.TenantRepository,
+	renderer *templating.Renderer,
+	deduplicator *dedup.Deduplicator,
+	webhookSender *sender.WebhookSender,
+) *Scheduler {
 	_ = godotenv.Load()
 
 	intervalMinutes := getEnvAsInt("SCHEDULER_INTERVAL_MINUTES", 2)
 	messageLimit := getEnvAsInt("SCHEDULER_MESSAGE_LIMIT", 2)
+	claimTimeoutMinutes := getEnvAsInt("SCHEDULER_CLAIM_TIMEOUT_MINUTES", 10)
+	priorityAgingMinutes := getEnvAsInt("SCHEDULER_PRIORITY_AGING_MINUTES", 0)
+
+	guarantee := DeliveryGuarantee(os.Getenv("SCHEDULER_DELIVERY_GUARANTEE"))
+	switch guarantee {
+	case AtLeastOnce, AtMostOnce:
+	case "":
+		guarantee = AtLeastOnce
+	default:
+		slog.Warn("Invalid value for SCHEDULER_DELIVERY_GUARANTEE, using default", "default", AtLeastOnce)
+		guarantee = AtLeastOnce
+	}
 
 	return &Scheduler{
-		stopChan:      make(chan struct{}),
-		repo:          repo,
-		webhookSender: webhookSender,
-		interval:      time.Duration(intervalMinutes) * time.Minute,
-		messageLimit:  messageLimit,
+		stopChan:        make(chan struct{}),
+		repo:            repo,
+		outboxRepo:      outboxRepo,
+		suppressionRepo: suppressionRepo,
+		tenantRepo:      tenantRepo,
+		renderer:        renderer,
+		dedup:           deduplicator,
+		quietHours:      quiethours.LoadPolicyFromEnv(),
+		webhookSender:   webhookSender,
+		interval:        time.Duration(intervalMinutes) * time.Minute,
+		messageLimit:    messageLimit,
+		guarantee:       guarantee,
+		claimTimeout:    time.Duration(claimTimeoutMinutes) * time.Minute,
+		priorityAging:   time.Duration(priorityAgingMinutes) * time.Minute,
 	}
 }
 
@@ -52,10 +118,11 @@ func (s *Scheduler) Start() error {
 	}
 
 	s.isRunning = true
+	metrics.SetSchedulerRunning(true)
 	s.ticker = time.NewTicker(s.interval)
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 
-	log.Printf("Scheduler started - will send %d messages every %v", s.messageLimit, s.interval)
+	slog.Info("Scheduler started", "message_limit", s.messageLimit, "interval", s.interval, "guarantee", s.guarantee)
 
 	go s.run()
 
@@ -73,6 +140,7 @@ func (s *Scheduler) Stop() {
 	}
 
 	s.isRunning = false
+	metrics.SetSchedulerRunning(false)
 	if s.ticker != nil {
 		s.ticker.Stop()
 	}
@@ -80,7 +148,7 @@ func (s *Scheduler) Stop() {
 		s.cancel()
 	}
 
-	log.Println("Scheduler stopped")
+	slog.Info("Scheduler stopped")
 }
 
 func (s *Scheduler) IsRunning() bool {
@@ -103,52 +171,366 @@ func (s *Scheduler) run() {
 }
 
 func (s *Scheduler) processMessages() {
-	ctx := context.Background()
+	ctx, span := tracer.Start(context.Background(), "Scheduler.processMessages")
+	defer span.End()
+
+	var reclaimBefore *time.Time
+	expiredBefore := time.Now().Add(-s.claimTimeout)
+	if s.guarantee == AtLeastOnce {
+		reclaimBefore = &expiredBefore
+	} else {
+		expired, err := s.outboxRepo.FailExpiredClaims(ctx, expiredBefore)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to expire abandoned claims", "error", err)
+		} else if expired > 0 {
+			slog.WarnContext(ctx, "Marked abandoned messages as failed", "count", expired)
+		}
+	}
 
-	messages, err := s.repo.GetUnsentMessages(ctx, s.messageLimit)
+	// Loaded before claiming, so a failure cannot send a tenant's messages to
+	// the default webhook.
+	senders, err := s.tenantSenders(ctx)
 	if err != nil {
-		log.Printf("Failed to fetch unsent messages: %v", err)
+		slog.ErrorContext(ctx, "Failed to load tenants", "error", err)
+		recordSpanError(span, err)
 		return
 	}
 
-	if len(messages) == 0 {
-		log.Println("No unsent messages found")
+	claims, err := s.outboxRepo.ClaimMessages(ctx, repository.ClaimOptions{
+		Limit:         s.messageLimit,
+		ReclaimBefore: reclaimBefore,
+		PriorityAging: s.priorityAging,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to claim unsent messages", "error", err)
+		recordSpanError(span, err)
 		return
 	}
+	span.SetAttributes(attribute.Int("chronogo.batch.size", len(claims)))
 
-	log.Printf("Processing %d messages", len(messages))
+	if len(claims) == 0 {
+		slog.DebugContext(ctx, "No unsent messages found")
+		return
+	}
+
+	slog.InfoContext(ctx, "Processing messages", "count", len(claims))
+	startedAt := time.Now()
+	defer func() {
+		metrics.ObserveBatch(time.Since(startedAt))
+	}()
 
-	for _, msg := range messages {
-		if err := s.sendMessage(ctx, msg); err != nil {
-			log.Printf("Failed to send message ID %d: %v", msg.ID, err)
+	for _, claim := range claims {
+		webhook, ok := senders[claim.Message.TenantID]
+		if !ok {
+			webhook = s.webhookSender
 		}
+
+		msgCtx, msgSpan := tracer.Start(ctx, "Scheduler.sendMessage", trace.WithAttributes(
+			attribute.Int("chronogo.message.id", claim.Message.ID),
+			attribute.String("chronogo.tenant.id", claim.Message.TenantID),
+		))
+		// Every line logged while sending identifies the message. The
+		// recipient is masked by the log handler.
+		msgCtx = logging.With(msgCtx,
+			slog.Int("id", claim.Message.ID),
+			slog.String("to", claim.Message.To),
+			slog.String("tenant_id", claim.Message.TenantID),
+		)
+		if err := s.sendMessage(msgCtx, claim, webhook); err != nil {
+			slog.ErrorContext(msgCtx, "Failed to send message", "error", err)
+			recordSpanError(msgSpan, err)
+		}
+		msgSpan.End()
 	}
 }
 
-func (s *Scheduler) sendMessage(ctx context.Context, msg model.Message) error {
-	messageID, err := s.webhookSender.SendMessage(msg.To, msg.Content)
+func recordSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// tenantSenders returns the webhook sender of every tenant by tenant id.
+func (s *Scheduler) tenantSenders(ctx context.Context) (map[string]*sender.WebhookSender, error) {
+	tenants, err := s.tenantRepo.ListTenants(ctx)
 	if err != nil {
+		return nil, err
+	}
+
+	senders := make(map[string]*sender.WebhookSender, len(tenants))
+	for _, tenant := range tenants {
+		senders[tenant.ID] = s.webhookSender.ForTenant(tenant)
+	}
+	return senders, nil
+}
+
+func (s *Scheduler) sendMessage(ctx context.Context, claim repository.Claim, webhook *sender.WebhookSender) error {
+	if !claim.Message.Critical {
+		if until, ok := s.quietHours.Defer(time.Now(), claim.Message.Timezone); ok {
+			if err := s.outboxRepo.DeferMessage(ctx, claim, until); err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "Deferred message due to quiet hours", "until", until.Format(time.RFC3339))
+			return nil
+		}
+	}
+
+	if claim.Message.TemplateID != nil {
+		msg := claim.Message
+		content, err := s.renderer.Render(ctx, msg)
+		if err != nil {
+			// Rendering is deterministic, retrying would fail the same way.
+			if failErr := s.outboxRepo.FailAttempt(ctx, claim, failedAttempt(claim, err), model.StatusFailed); failErr != nil {
+				slog.ErrorContext(ctx, "Failed to record failed attempt", "error", failErr)
+			}
+			return err
+		}
+		claim.Message.Content = content
+	}
+
+	// Recipients may have unsubscribed after the message was queued.
+	suppression, err := s.suppressionRepo.GetSuppression(ctx, claim.Message.TenantID, claim.Message.To)
+	switch {
+	case err == nil:
+		reason := suppression.StatusReason()
+		attempt := failedAttempt(claim, errors.New(reason))
+		if err := s.outboxRepo.SkipMessage(ctx, claim, attempt, model.StatusSuppressed, reason); err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Suppressed message", "reason", reason)
+		return nil
+	case !errors.Is(err, repository.ErrSuppressionNotFound):
+		// Nothing was sent yet, so releasing the claim is safe for both guarantees.
+		if failErr := s.outboxRepo.FailAttempt(ctx, claim, failedAttempt(claim, err), model.StatusUnsent); failErr != nil {
+			slog.ErrorContext(ctx, "Failed to record failed attempt", "error", failErr)
+		}
 		return err
 	}
 
-	now := time.Now()
-	err = s.repo.UpdateMessageStatus(ctx, msg.ID, model.StatusSent, messageID, &now)
+	// Ingestion only sees messages accepted within the window; a copy queued
+	// earlier may have been sent since.
+	if s.dedup.Enabled() && claim.Message.DedupKey != "" {
+		existing, duplicate, err := s.repo.FindSentDuplicate(ctx, claim.Message, s.dedup.Window())
+		if err != nil {
+			if failErr := s.outboxRepo.FailAttempt(ctx, claim, failedAttempt(claim, err), model.StatusUnsent); failErr != nil {
+				slog.ErrorContext(ctx, "Failed to record failed attempt", "error", failErr)
+			}
+			return err
+		}
+		if duplicate {
+			reason := fmt.Sprintf("duplicate of message %d", existing)
+			attempt := failedAttempt(claim, errors.New(reason))
+			if err := s.outboxRepo.SkipMessage(ctx, claim, attempt, model.StatusDuplicate, reason); err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "Skipped message", "reason", reason)
+			return nil
+		}
+	}
+
+	msg := claim.Message
+
+	if _, parts := sms.Split(msg.Content); msg.Channel.Segmented() && len(parts) > 1 {
+		return s.sendParts(ctx, claim, parts, webhook)
+	}
+
+	result, sendErr := webhook.SendMessage(ctx, msg)
+	attempt := newAttempt(claim, result, sendErr)
+
+	if sendErr != nil {
+		metrics.MessageFailed(result.StatusCode)
+		if err := s.outboxRepo.FailAttempt(ctx, claim, attempt, s.retryStatus()); err != nil {
+			slog.ErrorContext(ctx, "Failed to record failed attempt", "error", err)
+		}
+		return sendErr
+	}
+
+	if err := s.completeSent(ctx, claim, attempt); err != nil {
+		return err
+	}
+	metrics.MessageSent(result.StatusCode)
+
+	messageID := result.MessageID
+	cacheProviderID(ctx, webhook.Tenant(), msg.ID, *messageID, *attempt.FinishedAt)
+
+	slog.InfoContext(ctx, "Sent message", "message_id", messageID)
+	return nil
+}
+
+// sendParts sends content too long for one segment as linked parts. Parts sent
+// by an earlier attempt are not sent again, and the message is only completed
+// once every part has been sent. The message keeps the provider id of the first
+// part.
+func (s *Scheduler) sendParts(ctx context.Context, claim repository.Claim, contents []string, webhook *sender.WebhookSender) error {
+	msg := claim.Message
+
+	recorded, err := s.outboxRepo.GetMessageParts(ctx, msg.ID)
 	if err != nil {
+		// Nothing was sent yet, so releasing the claim is safe for both guarantees.
+		if failErr := s.outboxRepo.FailAttempt(ctx, claim, failedAttempt(claim, err), model.StatusUnsent); failErr != nil {
+			slog.ErrorContext(ctx, "Failed to record failed attempt", "error", failErr)
+		}
 		return err
 	}
 
-	if redis.Client != nil {
-		if cacheErr := redis.CacheMessage(ctx, *messageID, now); cacheErr != nil {
-			log.Printf("Failed to cache message to Redis: %v", cacheErr)
-		} else {
-			log.Printf("Cached messageId %s to Redis", messageID.String())
+	startedAt := time.Now()
+	var firstID *uuid.UUID
+	var last *sender.SendResult
+	for i, content := range contents {
+		part := model.MessagePart{
+			MessageID: msg.ID,
+			Number:    i + 1,
+			Total:     len(contents),
+			Content:   content,
+		}
+		if prev, ok := recorded[part.Number]; ok && prev.Status == model.PartSent && prev.Content == content {
+			if part.Number == 1 {
+				firstID = prev.ProviderMessageID
+			}
+			continue
+		}
+
+		result, sendErr := webhook.SendPart(ctx, msg, part)
+		last = result
+		if sendErr != nil {
+			errMsg := sendErr.Error()
+			part.Status = model.PartFailed
+			part.Error = &errMsg
+			if err := s.outboxRepo.RecordPart(ctx, part); err != nil {
+				slog.ErrorContext(ctx, "Failed to record part", "part", part.Number, "error", err)
+			}
+
+			metrics.MessageFailed(result.StatusCode)
+			attempt := newAttempt(claim, result, fmt.Errorf("part %d/%d: %w", part.Number, part.Total, sendErr))
+			attempt.StartedAt = startedAt
+			if err := s.outboxRepo.FailAttempt(ctx, claim, attempt, s.retryStatus()); err != nil {
+				slog.ErrorContext(ctx, "Failed to record failed attempt", "error", err)
+			}
+			return sendErr
+		}
+
+		part.Status = model.PartSent
+		part.ProviderMessageID = result.MessageID
+		part.SentAt = &result.FinishedAt
+		// If this fails the part is sent again on retry, which at-least-once allows.
+		if err := s.outboxRepo.RecordPart(ctx, part); err != nil {
+			slog.ErrorContext(ctx, "Failed to record part", "part", part.Number, "error", err)
+		}
+		if part.Number == 1 {
+			firstID = result.MessageID
+		}
+		cacheProviderID(ctx, webhook.Tenant(), msg.ID, *result.MessageID, result.FinishedAt)
+	}
+
+	var attempt *model.MessageAttempt
+	if last != nil {
+		attempt = newAttempt(claim, last, nil)
+		attempt.StartedAt = startedAt
+		latency := attempt.FinishedAt.Sub(startedAt).Milliseconds()
+		attempt.LatencyMs = &latency
+	} else {
+		// Every part was sent by an earlier attempt that failed to complete.
+		now := time.Now()
+		var latency int64
+		attempt = &model.MessageAttempt{
+			ID:         claim.AttemptID,
+			MessageID:  msg.ID,
+			StartedAt:  now,
+			FinishedAt: &now,
+			LatencyMs:  &latency,
 		}
 	}
+	attempt.ProviderMessageID = firstID
 
-	log.Printf("Successfully sent message ID %d to %s (messageId: %s)", msg.ID, msg.To, messageID.String())
+	if err := s.completeSent(ctx, claim, attempt); err != nil {
+		return err
+	}
+	if attempt.StatusCode != nil {
+		metrics.MessageSent(*attempt.StatusCode)
+	}
+
+	slog.InfoContext(ctx, "Sent message", "message_id", firstID, "parts", len(contents))
 	return nil
 }
 
+// completeSent records a send the provider accepted and completes the
+// message. Neither step is cancelled with ctx, so stopping the scheduler does
+// not lose the outcome of a send that already happened. If completing fails
+// the claim stays in processing and, once it expires, is settled as sent from
+// the recorded attempt rather than sent again or failed.
+func (s *Scheduler) completeSent(ctx context.Context, claim repository.Claim, attempt *model.MessageAttempt) error {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.outboxRepo.RecordSentAttempt(ctx, claim, attempt); err != nil {
+		slog.ErrorContext(ctx, "Failed to record sent attempt", "error", err)
+	}
+	return s.outboxRepo.CompleteAttempt(ctx, claim, attempt)
+}
+
+// retryStatus is where a message goes after a failed send: back to unsent for
+// at-least-once, failed for at-most-once.
+func (s *Scheduler) retryStatus() model.MessageStatus {
+	if s.guarantee == AtMostOnce {
+		return model.StatusFailed
+	}
+	return model.StatusUnsent
+}
+
+// cacheProviderID lets delivery receipts for messageID from webhookTenant's
+// webhook, or the shared one, be mapped to the message without a database
+// lookup.
+func cacheProviderID(ctx context.Context, webhookTenant string, id int, messageID uuid.UUID, sentAt time.Time) {
+	if redis.Client == nil {
+		return
+	}
+	if err := redis.CacheMessage(ctx, webhookTenant, id, messageID, sentAt); err != nil {
+		slog.WarnContext(ctx, "Failed to cache message to Redis", "message_id", messageID, "error", err)
+	} else {
+		slog.DebugContext(ctx, "Cached message to Redis", "message_id", messageID)
+	}
+}
+
+// failedAttempt builds the attempt for a message that failed or was skipped
+// before the webhook was called.
+func failedAttempt(claim repository.Claim, cause error) *model.MessageAttempt {
+	now := time.Now()
+	var latency int64
+	errMsg := cause.Error()
+
+	return &model.MessageAttempt{
+		ID:         claim.AttemptID,
+		MessageID:  claim.Message.ID,
+		StartedAt:  now,
+		FinishedAt: &now,
+		LatencyMs:  &latency,
+		Error:      &errMsg,
+	}
+}
+
+func newAttempt(claim repository.Claim, result *sender.SendResult, sendErr error) *model.MessageAttempt {
+	finishedAt := result.FinishedAt
+	latency := result.Latency().Milliseconds()
+
+	attempt := &model.MessageAttempt{
+		ID:                claim.AttemptID,
+		MessageID:         claim.Message.ID,
+		StartedAt:         result.StartedAt,
+		FinishedAt:        &finishedAt,
+		LatencyMs:         &latency,
+		ProviderMessageID: result.MessageID,
+	}
+	if result.StatusCode != 0 {
+		attempt.StatusCode = &result.StatusCode
+	}
+	if result.ResponseBody != "" {
+		attempt.ResponseBody = &result.ResponseBody
+	}
+	if sendErr != nil {
+		errMsg := sendErr.Error()
+		attempt.Error = &errMsg
+	}
+
+	return attempt
+}
+
 func getEnvAsInt(key string, defaultValue int) int {
 	value := os.Getenv(key)
 	if value == "" {
@@ -156,7 +538,7 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	intValue, err := strconv.Atoi(value)
 	if err != nil {
-		log.Printf("Invalid value for %s, using default %d", key, defaultValue)
+		slog.Warn("Invalid value for "+key+", using default", "default", defaultValue)
 		return defaultValue
 	}
 	return intValue
@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+	"github.com/kubilayrn/ChronoGo/internal/sender"
+)
+
+func TestRetryStatus(t *testing.T) {
+	tests := []struct {
+		guarantee DeliveryGuarantee
+		want      model.MessageStatus
+	}{
+		{guarantee: AtLeastOnce, want: model.StatusUnsent},
+		{guarantee: AtMostOnce, want: model.StatusFailed},
+	}
+
+	for _, tt := range tests {
+		s := &Scheduler{guarantee: tt.guarantee}
+		if got := s.retryStatus(); got != tt.want {
+			t.Errorf("retryStatus() with %s = %s, want %s", tt.guarantee, got, tt.want)
+		}
+	}
+}
+
+func TestNewAttempt(t *testing.T) {
+	claim := repository.Claim{Message: model.Message{ID: 7}, AttemptID: 3}
+	startedAt := time.Date(2025, 11, 2, 21, 38, 4, 0, time.UTC)
+	providerID := uuid.New()
+
+	tests := []struct {
+		name           string
+		result         *sender.SendResult
+		sendErr        error
+		wantStatusCode bool
+		wantBody       bool
+		wantProviderID bool
+		wantErr        string
+	}{
+		{
+			name:           "accepted",
+			result:         &sender.SendResult{MessageID: &providerID, StatusCode: 202, ResponseBody: `{"messageId":"x"}`, StartedAt: startedAt, FinishedAt: startedAt.Add(250 * time.Millisecond)},
+			wantStatusCode: true,
+			wantBody:       true,
+			wantProviderID: true,
+		},
+		{
+			name:           "rejected",
+			result:         &sender.SendResult{StatusCode: 500, ResponseBody: "oops", StartedAt: startedAt, FinishedAt: startedAt.Add(250 * time.Millisecond)},
+			sendErr:        errors.New("unexpected status code: 500"),
+			wantStatusCode: true,
+			wantBody:       true,
+			wantErr:        "unexpected status code: 500",
+		},
+		{
+			name:    "unreachable",
+			result:  &sender.SendResult{StartedAt: startedAt, FinishedAt: startedAt.Add(250 * time.Millisecond)},
+			sendErr: errors.New("failed to send request: connection refused"),
+			wantErr: "failed to send request: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempt := newAttempt(claim, tt.result, tt.sendErr)
+
+			if attempt.ID != claim.AttemptID || attempt.MessageID != claim.Message.ID {
+				t.Errorf("attempt ids = %d, %d, want %d, %d", attempt.ID, attempt.MessageID, claim.AttemptID, claim.Message.ID)
+			}
+			if attempt.LatencyMs == nil || *attempt.LatencyMs != 250 {
+				t.Errorf("LatencyMs = %v, want 250", attempt.LatencyMs)
+			}
+			if attempt.FinishedAt == nil || !attempt.FinishedAt.Equal(tt.result.FinishedAt) {
+				t.Errorf("FinishedAt = %v, want %v", attempt.FinishedAt, tt.result.FinishedAt)
+			}
+			if (attempt.StatusCode != nil) != tt.wantStatusCode {
+				t.Errorf("StatusCode = %v, want set %v", attempt.StatusCode, tt.wantStatusCode)
+			}
+			if (attempt.ResponseBody != nil) != tt.wantBody {
+				t.Errorf("ResponseBody = %v, want set %v", attempt.ResponseBody, tt.wantBody)
+			}
+			if (attempt.ProviderMessageID != nil) != tt.wantProviderID {
+				t.Errorf("ProviderMessageID = %v, want set %v", attempt.ProviderMessageID, tt.wantProviderID)
+			}
+			var gotErr string
+			if attempt.Error != nil {
+				gotErr = *attempt.Error
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("Error = %q, want %q", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFailedAttempt(t *testing.T) {
+	claim := repository.Claim{Message: model.Message{ID: 7}, AttemptID: 3}
+
+	attempt := failedAttempt(claim, errors.New("suppressed: unsubscribed"))
+
+	if attempt.ID != 3 || attempt.MessageID != 7 {
+		t.Errorf("attempt ids = %d, %d, want 3, 7", attempt.ID, attempt.MessageID)
+	}
+	if attempt.FinishedAt == nil || attempt.LatencyMs == nil || *attempt.LatencyMs != 0 {
+		t.Errorf("attempt = %+v, want finished with zero latency", attempt)
+	}
+	if attempt.Error == nil || *attempt.Error != "suppressed: unsubscribed" {
+		t.Errorf("Error = %v, want the cause", attempt.Error)
+	}
+	if attempt.StatusCode != nil || attempt.ProviderMessageID != nil {
+		t.Errorf("attempt = %+v, want no webhook response", attempt)
+	}
+}
@@ -0,0 +1,129 @@
+package quiethours
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Policy is a daily window, in the recipient's local time, during which
+// non-critical messages are not sent.
+type Policy struct {
+	start    time.Duration
+	end      time.Duration
+	location *time.Location
+	enabled  bool
+}
+
+// LoadPolicyFromEnv reads QUIET_HOURS_START and QUIET_HOURS_END (HH:MM) and
+// QUIET_HOURS_TIMEZONE, the zone used for recipients without their own. Quiet
+// hours are disabled unless both start and end are set.
+func LoadPolicyFromEnv() *Policy {
+	_ = godotenv.Load()
+
+	policy := &Policy{location: time.UTC}
+
+	if name := os.Getenv("QUIET_HOURS_TIMEZONE"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			slog.Warn("Invalid value for QUIET_HOURS_TIMEZONE, using default UTC")
+		} else {
+			policy.location = loc
+		}
+	}
+
+	startValue, endValue := os.Getenv("QUIET_HOURS_START"), os.Getenv("QUIET_HOURS_END")
+	if startValue == "" || endValue == "" {
+		return policy
+	}
+
+	start, err := parseClock(startValue)
+	if err != nil {
+		slog.Warn("Invalid value for QUIET_HOURS_START, quiet hours disabled", "error", err)
+		return policy
+	}
+	end, err := parseClock(endValue)
+	if err != nil {
+		slog.Warn("Invalid value for QUIET_HOURS_END, quiet hours disabled", "error", err)
+		return policy
+	}
+	if start == end {
+		slog.Warn("QUIET_HOURS_START equals QUIET_HOURS_END, quiet hours disabled")
+		return policy
+	}
+
+	policy.start = start
+	policy.end = end
+	policy.enabled = true
+	return policy
+}
+
+func (p *Policy) Enabled() bool {
+	return p.enabled
+}
+
+// Defer reports whether now falls in quiet hours for a recipient in timezone
+// and, if so, when the window ends. An empty or unknown timezone uses the
+// policy's default zone.
+func (p *Policy) Defer(now time.Time, timezone string) (time.Time, bool) {
+	if !p.enabled {
+		return time.Time{}, false
+	}
+
+	loc := p.location
+	if timezone != "" {
+		if recipientLoc, err := time.LoadLocation(timezone); err == nil {
+			loc = recipientLoc
+		}
+	}
+
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	sinceMidnight := local.Sub(midnight)
+
+	if p.start < p.end {
+		// e.g. 13:00-14:00, within a single day
+		if sinceMidnight >= p.start && sinceMidnight < p.end {
+			return atClock(midnight, p.end), true
+		}
+		return time.Time{}, false
+	}
+
+	// e.g. 22:00-08:00, across midnight
+	switch {
+	case sinceMidnight >= p.start:
+		return atClock(midnight.AddDate(0, 0, 1), p.end), true
+	case sinceMidnight < p.end:
+		return atClock(midnight, p.end), true
+	}
+	return time.Time{}, false
+}
+
+// ValidateTimezone checks that name is an IANA time zone such as Europe/Istanbul.
+func ValidateTimezone(name string) error {
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("invalid timezone %q, must be an IANA name such as Europe/Istanbul", name)
+	}
+	return nil
+}
+
+// atClock returns the wall clock time offset after midnight, which is not
+// midnight.Add(offset) on days with a DST change.
+func atClock(midnight time.Time, offset time.Duration) time.Time {
+	return time.Date(
+		midnight.Year(), midnight.Month(), midnight.Day(),
+		int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0,
+		midnight.Location(),
+	)
+}
+
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
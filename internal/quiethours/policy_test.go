@@ -0,0 +1,59 @@
+package quiethours
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicyDefer(t *testing.T) {
+	overnight := &Policy{start: 22 * time.Hour, end: 8 * time.Hour, location: time.UTC, enabled: true}
+	lunch := &Policy{start: 13 * time.Hour, end: 14 * time.Hour, location: time.UTC, enabled: true}
+	earlyMorning := &Policy{start: time.Hour, end: 3 * time.Hour, location: time.UTC, enabled: true}
+
+	utc := func(t *testing.T, value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name     string
+		policy   *Policy
+		now      string
+		timezone string
+		until    string
+		deferred bool
+	}{
+		{"disabled", &Policy{location: time.UTC}, "2026-01-10T23:00:00Z", "", "", false},
+		{"before overnight window", overnight, "2026-01-10T21:59:00Z", "", "", false},
+		{"start of overnight window", overnight, "2026-01-10T22:00:00Z", "", "2026-01-11T08:00:00Z", true},
+		{"after midnight in overnight window", overnight, "2026-01-11T03:00:00Z", "", "2026-01-11T08:00:00Z", true},
+		{"end of overnight window", overnight, "2026-01-11T08:00:00Z", "", "", false},
+		{"within single day window", lunch, "2026-01-10T13:30:00Z", "", "2026-01-10T14:00:00Z", true},
+		{"end of single day window", lunch, "2026-01-10T14:00:00Z", "", "", false},
+		{"recipient timezone", overnight, "2026-01-10T20:00:00Z", "Europe/Istanbul", "2026-01-11T05:00:00Z", true},
+		{"recipient timezone outside window", overnight, "2026-01-10T18:00:00Z", "Europe/Istanbul", "", false},
+		{"unknown timezone uses default", overnight, "2026-01-10T23:00:00Z", "Mars/Olympus_Mons", "2026-01-11T08:00:00Z", true},
+		// Clocks go forward at 02:00 on 2026-03-08, so 03:00 is two hours
+		// after midnight rather than three.
+		{"dst change", earlyMorning, "2026-03-08T06:30:00Z", "America/New_York", "2026-03-08T07:00:00Z", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, deferred := tt.policy.Defer(utc(t, tt.now), tt.timezone)
+			if deferred != tt.deferred {
+				t.Fatalf("deferred = %v, want %v", deferred, tt.deferred)
+			}
+			if !deferred {
+				return
+			}
+			if want := utc(t, tt.until); !until.Equal(want) {
+				t.Errorf("until = %s, want %s", until.UTC().Format(time.RFC3339), tt.until)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/redis"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+)
+
+// tenantsTTL is how long tenant limits are cached, so a changed limit takes
+// effect within it without reading tenants on every request.
+const tenantsTTL = 30 * time.Second
+
+// tenantsRefreshTimeout bounds one read of the tenants.
+const tenantsRefreshTimeout = 10 * time.Second
+
+// tenantLister reads the tenants and their limits.
+type tenantLister interface {
+	ListTenants(ctx context.Context) ([]model.Tenant, error)
+}
+
+// tenantSnapshot is the tenants as of one read.
+type tenantSnapshot struct {
+	tenants map[string]model.Tenant
+	// loadedAt is when the read was attempted; a failed read keeps the
+	// previous tenants with a new loadedAt so it is not retried on every
+	// request.
+	loadedAt time.Time
+}
+
+// Result describes the window a request was counted in.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is when the window ends and the count starts over.
+	Reset time.Time
+}
+
+// RetryAfter is how long a rejected client should wait.
+func (r Result) RetryAfter(now time.Time) time.Duration {
+	if wait := r.Reset.Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Limiter counts requests per client in fixed windows. Counts are kept in
+// Redis so every instance shares them; without Redis, or when a Redis call
+// fails, each instance counts on its own.
+type Limiter struct {
+	window time.Duration
+	// callerLimit, tenantLimit and ipLimit are the requests allowed per
+	// window for an authenticated caller, for all callers of a tenant and for
+	// an IP address. Zero disables the limit. Tenants may replace the first
+	// two.
+	callerLimit int
+	tenantLimit int
+	ipLimit     int
+
+	tenantRepo tenantLister
+	// tenants is replaced by refreshTenants, never modified, so requests read
+	// it without a lock.
+	tenants    atomic.Pointer[tenantSnapshot]
+	refreshing atomic.Bool
+
+	mu     sync.Mutex
+	counts map[string]int
+	// windowStart is the window counts belong to.
+	windowStart time.Time
+}
+
+// NewLimiterFromEnv reads RATE_LIMIT_WINDOW_SECONDS, RATE_LIMIT_PER_KEY,
+// RATE_LIMIT_PER_TENANT and RATE_LIMIT_PER_IP. The limits of each tenant are
+// read through tenantRepo.
+func NewLimiterFromEnv(tenantRepo *repository.TenantRepository) *Limiter {
+	_ = godotenv.Load()
+
+	windowSeconds := getEnvAsInt("RATE_LIMIT_WINDOW_SECONDS", 60)
+	if windowSeconds == 0 {
+		slog.Warn("Invalid value for RATE_LIMIT_WINDOW_SECONDS, using default 60")
+		windowSeconds = 60
+	}
+
+	l := &Limiter{
+		window:      time.Duration(windowSeconds) * time.Second,
+		callerLimit: getEnvAsInt("RATE_LIMIT_PER_KEY", 600),
+		tenantLimit: getEnvAsInt("RATE_LIMIT_PER_TENANT", 0),
+		ipLimit:     getEnvAsInt("RATE_LIMIT_PER_IP", 1200),
+		counts:      make(map[string]int),
+	}
+	if tenantRepo != nil {
+		l.tenantRepo = tenantRepo
+	}
+	return l
+}
+
+// AllowCaller counts a request by an authenticated caller of tenantID against
+// both the caller's and the tenant's limit, and returns the tighter result.
+// ok is false when neither is limited.
+func (l *Limiter) AllowCaller(ctx context.Context, tenantID, subject string) (result Result, ok bool) {
+	callerLimit, tenantLimit := l.limitsOf(ctx, tenantID)
+
+	if callerLimit > 0 {
+		result, ok = l.allow(ctx, "tenant:"+tenantID+":caller:"+subject, callerLimit), true
+	}
+	if tenantLimit > 0 {
+		tenantResult := l.allow(ctx, "tenant:"+tenantID, tenantLimit)
+		if !ok || tighter(tenantResult, result) {
+			result = tenantResult
+		}
+		ok = true
+	}
+	return result, ok
+}
+
+// tighter reports whether a leaves the client less room than b: a is rejected
+// while b is not, or has fewer requests remaining.
+func tighter(a, b Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	return a.Remaining < b.Remaining
+}
+
+// limitsOf returns the caller and tenant limits of tenantID, the defaults
+// unless the tenant replaces them.
+func (l *Limiter) limitsOf(ctx context.Context, tenantID string) (callerLimit, tenantLimit int) {
+	callerLimit, tenantLimit = l.callerLimit, l.tenantLimit
+
+	tenant, ok := l.tenant(ctx, tenantID)
+	if !ok {
+		return callerLimit, tenantLimit
+	}
+	if tenant.RateLimitPerKey != nil {
+		callerLimit = *tenant.RateLimitPerKey
+	}
+	if tenant.RateLimit != nil {
+		tenantLimit = *tenant.RateLimit
+	}
+	return callerLimit, tenantLimit
+}
+
+// tenant returns the cached settings of tenantID. Once the cache is older
+// than tenantsTTL one request starts reading all tenants again in the
+// background; until that finishes, or when it fails, every request keeps
+// using the previous settings. Before the first read finishes no tenant has
+// settings of its own.
+func (l *Limiter) tenant(ctx context.Context, tenantID string) (model.Tenant, bool) {
+	snapshot := l.tenants.Load()
+	stale := snapshot == nil || time.Since(snapshot.loadedAt) >= tenantsTTL
+	if l.tenantRepo != nil && stale && l.refreshing.CompareAndSwap(false, true) {
+		go l.refreshTenants(context.WithoutCancel(ctx), snapshot)
+	}
+
+	if snapshot == nil {
+		return model.Tenant{}, false
+	}
+	tenant, ok := snapshot.tenants[tenantID]
+	return tenant, ok
+}
+
+// refreshTenants reads the tenants into a new snapshot, keeping the tenants
+// of previous if that fails.
+func (l *Limiter) refreshTenants(ctx context.Context, previous *tenantSnapshot) {
+	defer l.refreshing.Store(false)
+
+	ctx, cancel := context.WithTimeout(ctx, tenantsRefreshTimeout)
+	defer cancel()
+
+	next := &tenantSnapshot{loadedAt: time.Now()}
+	tenants, err := l.tenantRepo.ListTenants(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load tenant rate limits, using previous ones", "error", err)
+		if previous != nil {
+			next.tenants = previous.tenants
+		}
+	} else {
+		next.tenants = make(map[string]model.Tenant, len(tenants))
+		for _, tenant := range tenants {
+			next.tenants[tenant.ID] = tenant
+		}
+	}
+	l.tenants.Store(next)
+}
+
+// AllowIP counts a request from ip. ok is false when IP addresses are not
+// limited.
+func (l *Limiter) AllowIP(ctx context.Context, ip string) (result Result, ok bool) {
+	if l.ipLimit == 0 {
+		return Result{}, false
+	}
+	return l.allow(ctx, "ip:"+ip, l.ipLimit), true
+}
+
+func (l *Limiter) allow(ctx context.Context, client string, limit int) Result {
+	now := time.Now()
+	start := now.Truncate(l.window)
+	result := Result{Limit: limit, Reset: start.Add(l.window)}
+
+	count, err := l.increment(ctx, client, start)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to count request in Redis, counting locally", "error", err)
+		count = l.incrementLocal(client, start)
+	}
+
+	result.Allowed = count <= limit
+	if remaining := limit - count; remaining > 0 {
+		result.Remaining = remaining
+	}
+	return result
+}
+
+func (l *Limiter) increment(ctx context.Context, client string, start time.Time) (int, error) {
+	if redis.Client == nil {
+		return l.incrementLocal(client, start), nil
+	}
+
+	key := fmt.Sprintf("%s:%d", client, start.Unix())
+	count, err := redis.IncrementRateLimit(ctx, key, l.window)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// incrementLocal counts in memory. Counts of earlier windows are dropped
+// when a new window starts, so the map only holds current clients.
+func (l *Limiter) incrementLocal(client string, start time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !start.Equal(l.windowStart) {
+		l.counts = make(map[string]int)
+		l.windowStart = start
+	}
+	l.counts[client]++
+	return l.counts[client]
+}
+
+func getEnvAsInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		slog.Warn("Invalid value for "+key+", using default", "default", defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
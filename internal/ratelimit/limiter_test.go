@@ -0,0 +1,246 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+// fakeTenants lists tenants, optionally failing or waiting for release
+// before it returns.
+type fakeTenants struct {
+	mu      sync.Mutex
+	tenants []model.Tenant
+	err     error
+	calls   int
+	release chan struct{}
+}
+
+func (f *fakeTenants) ListTenants(ctx context.Context) ([]model.Tenant, error) {
+	f.mu.Lock()
+	f.calls++
+	release := f.release
+	f.mu.Unlock()
+
+	if release != nil {
+		<-release
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tenants, f.err
+}
+
+func (f *fakeTenants) set(tenants []model.Tenant, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tenants, f.err = tenants, err
+}
+
+func (f *fakeTenants) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func newTestLimiter(tenants tenantLister) *Limiter {
+	return &Limiter{
+		window:      time.Minute,
+		callerLimit: 3,
+		tenantLimit: 0,
+		ipLimit:     5,
+		tenantRepo:  tenants,
+		counts:      make(map[string]int),
+	}
+}
+
+// waitForRefresh waits until no tenant refresh is running and a snapshot
+// has been stored.
+func waitForRefresh(t *testing.T, l *Limiter) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for l.refreshing.Load() || l.tenants.Load() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("tenant refresh did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTighter(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Result
+		want bool
+	}{
+		{name: "rejected before allowed", a: Result{Allowed: false}, b: Result{Allowed: true, Remaining: 5}, want: true},
+		{name: "allowed after rejected", a: Result{Allowed: true, Remaining: 5}, b: Result{Allowed: false}, want: false},
+		{name: "fewer remaining", a: Result{Allowed: true, Remaining: 1}, b: Result{Allowed: true, Remaining: 2}, want: true},
+		{name: "more remaining", a: Result{Allowed: true, Remaining: 2}, b: Result{Allowed: true, Remaining: 1}, want: false},
+		{name: "equal", a: Result{Allowed: true, Remaining: 1}, b: Result{Allowed: true, Remaining: 1}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tighter(tt.a, tt.b); got != tt.want {
+				t.Errorf("tighter(%+v, %+v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowCallerCountsLocally(t *testing.T) {
+	l := newTestLimiter(nil)
+	ctx := context.Background()
+
+	for i := 1; i <= 4; i++ {
+		result, ok := l.AllowCaller(ctx, model.DefaultTenant, "key")
+		if !ok {
+			t.Fatal("AllowCaller reported the caller as unlimited")
+		}
+		if want := i <= 3; result.Allowed != want {
+			t.Errorf("request %d: Allowed = %v, want %v", i, result.Allowed, want)
+		}
+		if result.Limit != 3 {
+			t.Errorf("request %d: Limit = %d, want 3", i, result.Limit)
+		}
+	}
+
+	if result, _ := l.AllowCaller(ctx, model.DefaultTenant, "other"); !result.Allowed {
+		t.Error("another caller was limited by the first caller's requests")
+	}
+}
+
+func TestAllowCallerUnlimited(t *testing.T) {
+	l := newTestLimiter(nil)
+	l.callerLimit = 0
+
+	if _, ok := l.AllowCaller(context.Background(), model.DefaultTenant, "key"); ok {
+		t.Error("AllowCaller reported a limit with caller and tenant limits disabled")
+	}
+}
+
+func TestAllowIP(t *testing.T) {
+	l := newTestLimiter(nil)
+	ctx := context.Background()
+
+	for i := 1; i <= 6; i++ {
+		result, ok := l.AllowIP(ctx, "192.0.2.1")
+		if !ok {
+			t.Fatal("AllowIP reported the IP as unlimited")
+		}
+		if want := i <= 5; result.Allowed != want {
+			t.Errorf("request %d: Allowed = %v, want %v", i, result.Allowed, want)
+		}
+	}
+
+	l.ipLimit = 0
+	if _, ok := l.AllowIP(ctx, "192.0.2.1"); ok {
+		t.Error("AllowIP reported a limit with the IP limit disabled")
+	}
+}
+
+func TestTenantLimitsReplaceDefaults(t *testing.T) {
+	tenants := &fakeTenants{tenants: []model.Tenant{
+		{ID: "acme", RateLimit: intPtr(100), RateLimitPerKey: intPtr(10)},
+		{ID: "quiet", RateLimitPerKey: intPtr(0)},
+	}}
+	l := newTestLimiter(tenants)
+	ctx := context.Background()
+
+	l.limitsOf(ctx, "acme")
+	waitForRefresh(t, l)
+
+	tests := []struct {
+		tenant         string
+		wantCaller     int
+		wantTenantWide int
+	}{
+		{tenant: "acme", wantCaller: 10, wantTenantWide: 100},
+		{tenant: "quiet", wantCaller: 0, wantTenantWide: 0},
+		{tenant: "unknown", wantCaller: 3, wantTenantWide: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tenant, func(t *testing.T) {
+			caller, tenantWide := l.limitsOf(ctx, tt.tenant)
+			if caller != tt.wantCaller || tenantWide != tt.wantTenantWide {
+				t.Errorf("limitsOf(%q) = %d, %d, want %d, %d", tt.tenant, caller, tenantWide, tt.wantCaller, tt.wantTenantWide)
+			}
+		})
+	}
+
+	result, _ := l.AllowCaller(ctx, "acme", "key")
+	if result.Limit != 10 {
+		t.Errorf("AllowCaller for acme used limit %d, want the tighter 10", result.Limit)
+	}
+}
+
+func TestTenantRefreshDoesNotBlockRequests(t *testing.T) {
+	tenants := &fakeTenants{
+		tenants: []model.Tenant{{ID: "acme", RateLimitPerKey: intPtr(10)}},
+		release: make(chan struct{}),
+	}
+	l := newTestLimiter(tenants)
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 3 {
+			if caller, _ := l.limitsOf(ctx, "acme"); caller != 3 {
+				t.Errorf("limitsOf before the first read = %d, want the default 3", caller)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("limitsOf waited for the tenant read")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for tenants.callCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	l.limitsOf(ctx, "acme")
+	if calls := tenants.callCount(); calls != 1 {
+		t.Errorf("ListTenants called %d times while a read was running, want 1", calls)
+	}
+
+	close(tenants.release)
+	waitForRefresh(t, l)
+	if caller, _ := l.limitsOf(ctx, "acme"); caller != 10 {
+		t.Errorf("limitsOf after the read = %d, want 10", caller)
+	}
+}
+
+func TestTenantRefreshFailureKeepsLimits(t *testing.T) {
+	tenants := &fakeTenants{tenants: []model.Tenant{{ID: "acme", RateLimitPerKey: intPtr(10)}}}
+	l := newTestLimiter(tenants)
+	ctx := context.Background()
+
+	l.limitsOf(ctx, "acme")
+	waitForRefresh(t, l)
+
+	tenants.set(nil, errors.New("database is down"))
+	l.tenants.Store(&tenantSnapshot{tenants: l.tenants.Load().tenants, loadedAt: time.Now().Add(-tenantsTTL)})
+	l.limitsOf(ctx, "acme")
+	waitForRefresh(t, l)
+
+	if calls := tenants.callCount(); calls != 2 {
+		t.Fatalf("ListTenants called %d times, want 2", calls)
+	}
+	if caller, _ := l.limitsOf(ctx, "acme"); caller != 10 {
+		t.Errorf("limitsOf after a failed read = %d, want the previous 10", caller)
+	}
+	if calls := tenants.callCount(); calls != 2 {
+		t.Errorf("failed read was retried before tenantsTTL, ListTenants called %d times", calls)
+	}
+}
@@ -0,0 +1,165 @@
+package recipient
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+const maxEmailLength = 254
+
+var ErrUnknownChannel = errors.New("unknown channel")
+
+// Validator checks a recipient and returns its canonical form.
+type Validator interface {
+	Normalize(to string) (string, error)
+}
+
+// PhoneValidator normalises phone numbers to E.164 (+ followed by 8 to 15
+// digits). National numbers with a leading 0 are prefixed with
+// DefaultCountryCode when it is set.
+type PhoneValidator struct {
+	DefaultCountryCode string
+}
+
+func (v PhoneValidator) Normalize(to string) (string, error) {
+	var sb strings.Builder
+	for i, r := range strings.TrimSpace(to) {
+		switch {
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r == '+' && i == 0:
+			sb.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+			// common formatting characters
+		default:
+			return "", fmt.Errorf("phone number contains invalid character %q", r)
+		}
+	}
+
+	number := sb.String()
+	switch {
+	case strings.HasPrefix(number, "+"):
+	case strings.HasPrefix(number, "00"):
+		number = "+" + number[2:]
+	case strings.HasPrefix(number, "0") && v.DefaultCountryCode != "":
+		number = "+" + v.DefaultCountryCode + number[1:]
+	default:
+		return "", fmt.Errorf("phone number must include a country code, e.g. +905551111111")
+	}
+
+	digits := number[1:]
+	if len(digits) < 8 || len(digits) > 15 {
+		return "", fmt.Errorf("phone number must have 8 to 15 digits")
+	}
+	if digits[0] == '0' {
+		return "", fmt.Errorf("country code cannot start with 0")
+	}
+
+	return number, nil
+}
+
+// EmailValidator accepts a bare address such as user@example.com and
+// lowercases its domain.
+type EmailValidator struct{}
+
+func (EmailValidator) Normalize(to string) (string, error) {
+	to = strings.TrimSpace(to)
+	if len(to) > maxEmailLength {
+		return "", fmt.Errorf("email address is longer than %d characters", maxEmailLength)
+	}
+
+	addr, err := mail.ParseAddress(to)
+	if err != nil || addr.Address != to || addr.Name != "" {
+		return "", fmt.Errorf("invalid email address")
+	}
+
+	at := strings.LastIndex(to, "@")
+	domain := to[at+1:]
+	if !strings.Contains(domain, ".") {
+		return "", fmt.Errorf("email domain must be fully qualified")
+	}
+
+	return to[:at+1] + strings.ToLower(domain), nil
+}
+
+// NoopValidator accepts any non-empty recipient unchanged.
+type NoopValidator struct{}
+
+func (NoopValidator) Normalize(to string) (string, error) {
+	to = strings.TrimSpace(to)
+	if to == "" {
+		return "", fmt.Errorf("recipient is empty")
+	}
+	return to, nil
+}
+
+// Registry holds the validator used for each channel.
+type Registry struct {
+	validators map[model.Channel]Validator
+}
+
+// NewRegistryFromEnv configures a validator per channel from
+// RECIPIENT_VALIDATION_<CHANNEL>, which is one of phone, email or none.
+func NewRegistryFromEnv() *Registry {
+	_ = godotenv.Load()
+
+	phone := PhoneValidator{
+		DefaultCountryCode: strings.TrimPrefix(os.Getenv("RECIPIENT_DEFAULT_COUNTRY_CODE"), "+"),
+	}
+	kinds := map[string]Validator{
+		"phone": phone,
+		"email": EmailValidator{},
+		"none":  NoopValidator{},
+	}
+	defaults := map[model.Channel]string{
+		model.ChannelWebhook: "phone",
+		model.ChannelSMS:     "phone",
+		model.ChannelEmail:   "email",
+	}
+
+	validators := make(map[model.Channel]Validator, len(defaults))
+	for channel, defaultKind := range defaults {
+		key := "RECIPIENT_VALIDATION_" + strings.ToUpper(string(channel))
+		kind := os.Getenv(key)
+		if kind == "" {
+			kind = defaultKind
+		}
+		validator, ok := kinds[kind]
+		if !ok {
+			slog.Warn("Invalid value for "+key+", using default", "default", defaultKind)
+			validator = kinds[defaultKind]
+		}
+		validators[channel] = validator
+	}
+
+	return &Registry{validators: validators}
+}
+
+// Normalize validates to for channel. An empty channel means model.DefaultChannel.
+func (r *Registry) Normalize(channel model.Channel, to string) (string, error) {
+	if channel == "" {
+		channel = model.DefaultChannel
+	}
+	validator, ok := r.validators[channel]
+	if !ok {
+		return "", fmt.Errorf("%w %q, must be one of webhook, sms, email", ErrUnknownChannel, channel)
+	}
+	return validator.Normalize(to)
+}
+
+// NormalizeAny validates to as an email address if it contains an @ and as a
+// phone number otherwise. It is used where no channel is known, such as the
+// suppression list.
+func (r *Registry) NormalizeAny(to string) (string, error) {
+	if strings.Contains(to, "@") {
+		return r.Normalize(model.ChannelEmail, to)
+	}
+	return r.Normalize(model.ChannelSMS, to)
+}
@@ -0,0 +1,123 @@
+package recipient
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+type normalizeTest struct {
+	to      string
+	want    string
+	wantErr bool
+}
+
+func runNormalizeTests(t *testing.T, validator Validator, tests []normalizeTest) {
+	t.Helper()
+	for _, tt := range tests {
+		got, err := validator.Normalize(tt.to)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Normalize(%q) = %q, want error", tt.to, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Normalize(%q) returned error: %v", tt.to, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestPhoneValidator(t *testing.T) {
+	runNormalizeTests(t, PhoneValidator{}, []normalizeTest{
+		{to: "+905551111111", want: "+905551111111"},
+		{to: " +90 (555) 111-11.11 ", want: "+905551111111"},
+		{to: "00905551111111", want: "+905551111111"},
+		{to: "05551111111", wantErr: true},
+		{to: "+1234567", wantErr: true},
+		{to: "+12345678", want: "+12345678"},
+		{to: "+123456789012345", want: "+123456789012345"},
+		{to: "+1234567890123456", wantErr: true},
+		{to: "+05551111111", wantErr: true},
+		{to: "+90555111111a", wantErr: true},
+		{to: "90+5551111111", wantErr: true},
+		{to: "", wantErr: true},
+	})
+
+	runNormalizeTests(t, PhoneValidator{DefaultCountryCode: "90"}, []normalizeTest{
+		{to: "0555 111 11 11", want: "+905551111111"},
+		{to: "+445551111111", want: "+445551111111"},
+		{to: "5551111111", wantErr: true},
+	})
+}
+
+func TestEmailValidator(t *testing.T) {
+	runNormalizeTests(t, EmailValidator{}, []normalizeTest{
+		{to: "user@example.com", want: "user@example.com"},
+		{to: " User@Example.COM ", want: "User@example.com"},
+		{to: "first.last+tag@sub.example.com", want: "first.last+tag@sub.example.com"},
+		{to: "User <user@example.com>", wantErr: true},
+		{to: "user@localhost", wantErr: true},
+		{to: "user", wantErr: true},
+		{to: "@example.com", wantErr: true},
+		{to: strings.Repeat("a", 243) + "@example.com", wantErr: true},
+		{to: "", wantErr: true},
+	})
+}
+
+func TestNoopValidator(t *testing.T) {
+	runNormalizeTests(t, NoopValidator{}, []normalizeTest{
+		{to: " anything ", want: "anything"},
+		{to: "  ", wantErr: true},
+	})
+}
+
+func TestRegistryNormalize(t *testing.T) {
+	registry := &Registry{validators: map[model.Channel]Validator{
+		model.ChannelWebhook: PhoneValidator{},
+		model.ChannelSMS:     PhoneValidator{},
+		model.ChannelEmail:   EmailValidator{},
+	}}
+
+	tests := []struct {
+		channel model.Channel
+		to      string
+		want    string
+	}{
+		{"", "+90 555 111 11 11", "+905551111111"},
+		{model.ChannelSMS, "00905551111111", "+905551111111"},
+		{model.ChannelEmail, "user@Example.com", "user@example.com"},
+	}
+	for _, tt := range tests {
+		got, err := registry.Normalize(tt.channel, tt.to)
+		if err != nil {
+			t.Errorf("Normalize(%q, %q) returned error: %v", tt.channel, tt.to, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q, %q) = %q, want %q", tt.channel, tt.to, got, tt.want)
+		}
+	}
+
+	if _, err := registry.Normalize("fax", "+905551111111"); !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("Normalize with unknown channel returned %v, want ErrUnknownChannel", err)
+	}
+
+	runNormalizeTests(t, validatorFunc(registry.NormalizeAny), []normalizeTest{
+		{to: "user@Example.com", want: "user@example.com"},
+		{to: "+90 555 111 11 11", want: "+905551111111"},
+		{to: "user@", wantErr: true},
+	})
+}
+
+type validatorFunc func(to string) (string, error)
+
+func (f validatorFunc) Normalize(to string) (string, error) {
+	return f(to)
+}
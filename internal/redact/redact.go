@@ -0,0 +1,35 @@
+package redact
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Recipient hides most of a phone number or the local part of an email
+// address, keeping enough to tell recipients apart:
+// +905551234567 becomes +90********67 and jane@example.com j***@example.com.
+func Recipient(to string) string {
+	if at := strings.LastIndex(to, "@"); at > 0 {
+		return maskMiddle(to[:at], 1, 0) + to[at:]
+	}
+	return maskMiddle(to, 3, 2)
+}
+
+// Content replaces message content with a placeholder giving only its length.
+func Content(content string) string {
+	if content == "" {
+		return ""
+	}
+	return fmt.Sprintf("[redacted %d chars]", utf8.RuneCountInString(content))
+}
+
+// maskMiddle replaces all but the first head and last tail runes of s with
+// asterisks. Strings too short to keep both are masked entirely.
+func maskMiddle(s string, head, tail int) string {
+	runes := []rune(s)
+	if len(runes) <= head+tail {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:head]) + strings.Repeat("*", len(runes)-head-tail) + string(runes[len(runes)-tail:])
+}
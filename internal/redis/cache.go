@@ -15,18 +15,26 @@ const (
 )
 
 type MessageCache struct {
+	ID        int       `json:"id"`
 	MessageID uuid.UUID `json:"message_id"`
 	SentAt    time.Time `json:"sent_at"`
+	// WebhookTenant is the tenant whose own webhook sent the message, empty
+	// for the shared webhook.
+	WebhookTenant string `json:"webhook_tenant,omitempty"`
 }
 
-func CacheMessage(ctx context.Context, messageID uuid.UUID, sentAt time.Time) error {
+// CacheMessage stores the send result under the provider messageId so delivery
+// receipts can be mapped back to the row id without a database lookup.
+func CacheMessage(ctx context.Context, webhookTenant string, id int, messageID uuid.UUID, sentAt time.Time) error {
 	if Client == nil {
 		return fmt.Errorf("Redis client is not initialized")
 	}
 
 	cache := MessageCache{
-		MessageID: messageID,
-		SentAt:    sentAt,
+		ID:            id,
+		MessageID:     messageID,
+		SentAt:        sentAt,
+		WebhookTenant: webhookTenant,
 	}
 
 	data, err := json.Marshal(cache)
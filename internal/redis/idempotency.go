@@ -0,0 +1,145 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	idempotencyKeyPrefix = "idempotency:"
+	idempotencyTTL       = 24 * time.Hour
+)
+
+// ErrIdempotencyReservationLost is returned when a reservation expired, and
+// possibly went to another request, before its response was stored.
+var ErrIdempotencyReservationLost = errors.New("idempotency key reservation was lost")
+
+// storeIdempotentResponseScript replaces a reservation with the response only
+// while it still holds the reservation's token.
+var storeIdempotentResponseScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current or cjson.decode(current).token ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// releaseIdempotencyKeyScript deletes a reservation only while it still holds
+// the reservation's token.
+var releaseIdempotencyKeyScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current and cjson.decode(current).token == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// IdempotentResponse is the first response to a request with an
+// Idempotency-Key. Until the request finishes only RequestHash and Token are
+// set and Pending is true.
+type IdempotentResponse struct {
+	RequestHash string `json:"request_hash"`
+	Pending     bool   `json:"pending,omitempty"`
+	// Token identifies the request holding a reservation, so a request whose
+	// reservation expired cannot overwrite or release another request's.
+	Token       string `json:"token,omitempty"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// ReserveIdempotencyKey claims key for a request with requestHash for at most
+// pendingTTL, and returns the reservation's token. If the key was already
+// claimed it returns the stored entry instead and the token is empty.
+func ReserveIdempotencyKey(
+	ctx context.Context,
+	key, requestHash string,
+	pendingTTL time.Duration,
+) (stored *IdempotentResponse, token string, err error) {
+	if Client == nil {
+		return nil, "", fmt.Errorf("Redis client is not initialized")
+	}
+
+	token = uuid.NewString()
+	data, err := json.Marshal(IdempotentResponse{RequestHash: requestHash, Pending: true, Token: token})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal idempotency entry: %w", err)
+	}
+
+	redisKey := idempotencyKeyPrefix + key
+	ok, err := Client.SetNX(ctx, redisKey, data, pendingTTL).Result()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if ok {
+		return nil, token, nil
+	}
+
+	existing, err := Client.Get(ctx, redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Expired or released between SETNX and GET; the client can retry.
+		return &IdempotentResponse{RequestHash: requestHash, Pending: true}, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get idempotency entry: %w", err)
+	}
+
+	var entry IdempotentResponse
+	if err := json.Unmarshal(existing, &entry); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal idempotency entry: %w", err)
+	}
+	entry.Token = ""
+
+	return &entry, "", nil
+}
+
+// StoreIdempotentResponse replaces the reservation with token for key with
+// the response to replay, kept for idempotencyTTL. It returns
+// ErrIdempotencyReservationLost if key no longer holds the reservation.
+func StoreIdempotentResponse(ctx context.Context, key, token string, response IdempotentResponse) error {
+	if Client == nil {
+		return fmt.Errorf("Redis client is not initialized")
+	}
+
+	response.Pending = false
+	response.Token = ""
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency entry: %w", err)
+	}
+
+	stored, err := storeIdempotentResponseScript.Run(
+		ctx, Client, []string{idempotencyKeyPrefix + key}, token, data, idempotencyTTL.Milliseconds(),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	if stored == 0 {
+		return ErrIdempotencyReservationLost
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey drops the reservation with token for key so the
+// request can be retried, e.g. after an internal error. A reservation that
+// already expired, or went to another request, is left alone.
+func ReleaseIdempotencyKey(ctx context.Context, key, token string) error {
+	if Client == nil {
+		return fmt.Errorf("Redis client is not initialized")
+	}
+
+	err := releaseIdempotencyKeyScript.Run(ctx, Client, []string{idempotencyKeyPrefix + key}, token).Err()
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
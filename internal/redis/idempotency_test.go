@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// useMiniredis points Client at an in-memory Redis for the test.
+func useMiniredis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	server := miniredis.RunT(t)
+	previous := Client
+	Client = redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		Client.Close()
+		Client = previous
+	})
+	return server
+}
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	useMiniredis(t)
+	ctx := context.Background()
+
+	stored, token, err := ReserveIdempotencyKey(ctx, "k", "hash", time.Minute)
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey returned error: %v", err)
+	}
+	if stored != nil || token == "" {
+		t.Fatalf("ReserveIdempotencyKey = %+v, %q, want a reservation", stored, token)
+	}
+
+	stored, second, err := ReserveIdempotencyKey(ctx, "k", "hash", time.Minute)
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey returned error: %v", err)
+	}
+	if second != "" {
+		t.Fatalf("second ReserveIdempotencyKey reserved the key again")
+	}
+	if !stored.Pending || stored.RequestHash != "hash" || stored.Token != "" {
+		t.Errorf("second ReserveIdempotencyKey returned %+v, want the pending entry without its token", stored)
+	}
+
+	response := IdempotentResponse{RequestHash: "hash", StatusCode: 201, ContentType: "application/json", Body: []byte(`{}`)}
+	if err := StoreIdempotentResponse(ctx, "k", token, response); err != nil {
+		t.Fatalf("StoreIdempotentResponse returned error: %v", err)
+	}
+
+	stored, _, err = ReserveIdempotencyKey(ctx, "k", "hash", time.Minute)
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey returned error: %v", err)
+	}
+	if stored.Pending || stored.StatusCode != 201 || string(stored.Body) != `{}` {
+		t.Errorf("ReserveIdempotencyKey after storing returned %+v, want the stored response", stored)
+	}
+}
+
+func TestExpiredIdempotencyReservation(t *testing.T) {
+	server := useMiniredis(t)
+	ctx := context.Background()
+
+	_, expired, err := ReserveIdempotencyKey(ctx, "k", "hash", time.Minute)
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey returned error: %v", err)
+	}
+	server.FastForward(2 * time.Minute)
+
+	_, current, err := ReserveIdempotencyKey(ctx, "k", "hash", time.Minute)
+	if err != nil || current == "" {
+		t.Fatalf("ReserveIdempotencyKey after expiry = %q, %v, want a new reservation", current, err)
+	}
+
+	err = StoreIdempotentResponse(ctx, "k", expired, IdempotentResponse{RequestHash: "hash", StatusCode: 201})
+	if !errors.Is(err, ErrIdempotencyReservationLost) {
+		t.Errorf("StoreIdempotentResponse with an expired token returned %v, want ErrIdempotencyReservationLost", err)
+	}
+	if err := ReleaseIdempotencyKey(ctx, "k", expired); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey returned error: %v", err)
+	}
+	if !server.Exists(idempotencyKeyPrefix + "k") {
+		t.Fatal("ReleaseIdempotencyKey with an expired token dropped another request's reservation")
+	}
+
+	if err := ReleaseIdempotencyKey(ctx, "k", current); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey returned error: %v", err)
+	}
+	if server.Exists(idempotencyKeyPrefix + "k") {
+		t.Error("ReleaseIdempotencyKey with the current token kept the reservation")
+	}
+}
+
+func TestReleaseKeepsStoredResponse(t *testing.T) {
+	server := useMiniredis(t)
+	ctx := context.Background()
+
+	_, token, err := ReserveIdempotencyKey(ctx, "k", "hash", time.Minute)
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey returned error: %v", err)
+	}
+	if err := StoreIdempotentResponse(ctx, "k", token, IdempotentResponse{RequestHash: "hash", StatusCode: 201}); err != nil {
+		t.Fatalf("StoreIdempotentResponse returned error: %v", err)
+	}
+	if err := ReleaseIdempotencyKey(ctx, "k", token); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey returned error: %v", err)
+	}
+	if !server.Exists(idempotencyKeyPrefix + "k") {
+		t.Error("ReleaseIdempotencyKey dropped a stored response")
+	}
+	if ttl := server.TTL(idempotencyKeyPrefix + "k"); ttl != idempotencyTTL {
+		t.Errorf("stored response TTL = %v, want %v", ttl, idempotencyTTL)
+	}
+}
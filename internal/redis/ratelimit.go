@@ -0,0 +1,28 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const rateLimitKeyPrefix = "ratelimit:"
+
+// IncrementRateLimit counts a request against key and returns the count so
+// far. Keys should name the window they count, as they expire window after
+// the last request.
+func IncrementRateLimit(ctx context.Context, key string, window time.Duration) (int64, error) {
+	if Client == nil {
+		return 0, fmt.Errorf("Redis client is not initialized")
+	}
+
+	redisKey := rateLimitKeyPrefix + key
+	pipe := Client.TxPipeline()
+	incr := pipe.Incr(ctx, redisKey)
+	pipe.Expire(ctx, redisKey, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment rate limit: %w", err)
+	}
+
+	return incr.Val(), nil
+}
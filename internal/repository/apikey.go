@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+const apiKeyColumns = `id, tenant_id, name, prefix, scopes, created_at, last_used_at, revoked_at`
+
+type APIKeyRepository struct{}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{}
+}
+
+// CreateAPIKey stores key with the hash of its secret.
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *model.APIKey, hash string) error {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, tenant_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + apiKeyColumns + `
+	`
+
+	err := scanAPIKey(database.DB.QueryRow(ctx, query, key.Name, key.Prefix, hash, key.Scopes, key.TenantID), key)
+	if isForeignKeyViolation(err) {
+		return ErrTenantNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return nil
+}
+
+// GetActiveAPIKey returns the unrevoked key with hash and records that it was
+// used.
+func (r *APIKeyRepository) GetActiveAPIKey(ctx context.Context, hash string) (*model.APIKey, error) {
+	// last_used_at is only refreshed once a minute, so busy keys are not
+	// written, and locked, on every request. Staleness is decided by the
+	// database, whose clock last_used_at comes from.
+	query := `
+		SELECT ` + apiKeyColumns + `,
+			last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute'
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`
+
+	var key model.APIKey
+	var stale bool
+	err := scanAPIKey(database.DB.QueryRow(ctx, query, hash), &key, &stale)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	// The condition is repeated so concurrent requests that both saw a stale
+	// value write only once.
+	if stale {
+		_, err := database.DB.Exec(ctx, `
+			UPDATE api_keys
+			SET last_used_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+		`, key.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record api key use: %w", err)
+		}
+	}
+
+	return &key, nil
+}
+
+// ListAPIKeys returns the keys of tenantID, or of every tenant when tenantID
+// is empty, including revoked ones, newest first.
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context, tenantID string) ([]model.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE $1 = '' OR tenant_id = $1
+		ORDER BY id DESC
+	`
+
+	rows, err := database.DB.Query(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		var key model.APIKey
+		if err := scanAPIKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// GetAPIKey returns the key with id. As for ListAPIKeys, an empty tenantID
+// matches the keys of every tenant.
+func (r *APIKeyRepository) GetAPIKey(ctx context.Context, tenantID string, id int) (*model.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+	`
+
+	var key model.APIKey
+	err := scanAPIKey(database.DB.QueryRow(ctx, query, id, tenantID), &key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// RevokeAPIKey stops the key from authenticating. Revoking a revoked key keeps
+// the original revocation time. As for ListAPIKeys, an empty tenantID matches
+// the keys of every tenant.
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, tenantID string, id int) (*model.APIKey, error) {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+		RETURNING ` + apiKeyColumns + `
+	`
+
+	var key model.APIKey
+	err := scanAPIKey(database.DB.QueryRow(ctx, query, id, tenantID), &key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// scanAPIKey reads a row selected with apiKeyColumns, followed by any extra
+// columns into extra.
+func scanAPIKey(row pgx.Row, key *model.APIKey, extra ...any) error {
+	return row.Scan(append([]any{
+		&key.ID,
+		&key.TenantID,
+		&key.Name,
+		&key.Prefix,
+		&key.Scopes,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	}, extra...)...)
+}
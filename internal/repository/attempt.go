@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+type AttemptRepository struct{}
+
+func NewAttemptRepository() *AttemptRepository {
+	return &AttemptRepository{}
+}
+
+func (r *AttemptRepository) GetAttemptsByMessageID(ctx context.Context, messageID int) ([]model.MessageAttempt, error) {
+	query := `
+		SELECT id, message_id, started_at, finished_at, status_code, latency_ms,
+			response_body, provider_message_id, error, created_at
+		FROM message_attempts
+		WHERE message_id = $1
+		ORDER BY started_at ASC, id ASC
+	`
+
+	rows, err := database.DB.Query(ctx, query, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query message attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []model.MessageAttempt{}
+	for rows.Next() {
+		var attempt model.MessageAttempt
+
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.MessageID,
+			&attempt.StartedAt,
+			&attempt.FinishedAt,
+			&attempt.StatusCode,
+			&attempt.LatencyMs,
+			&attempt.ResponseBody,
+			&attempt.ProviderMessageID,
+			&attempt.Error,
+			&attempt.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message attempt: %w", err)
+		}
+
+		attempts = append(attempts, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// GetPartsByMessageID returns the recorded parts of a message sent in parts,
+// in order.
+func (r *AttemptRepository) GetPartsByMessageID(ctx context.Context, messageID int) ([]model.MessagePart, error) {
+	query := `
+		SELECT ` + partColumns + `
+		FROM message_parts
+		WHERE message_id = $1
+		ORDER BY number ASC
+	`
+
+	rows, err := database.DB.Query(ctx, query, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query message parts: %w", err)
+	}
+
+	return collectParts(rows)
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+const auditColumns = `id, tenant_id, actor, action, target_type, target_id, before, after, created_at`
+
+// AuditRepository appends to the audit log. The table rejects updates and
+// deletes, so entries cannot be changed once written.
+type AuditRepository struct{}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) RecordAudit(ctx context.Context, entry *model.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (tenant_id, actor, action, target_type, target_id, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + auditColumns + `
+	`
+
+	err := scanAuditEntry(database.DB.QueryRow(ctx, query,
+		entry.TenantID, entry.Actor, entry.Action, entry.TargetType, entry.TargetID,
+		nullIfEmptyJSON(entry.Before), nullIfEmptyJSON(entry.After),
+	), entry)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListAuditEntries returns the entries matching filter, newest first.
+func (r *AuditRepository) ListAuditEntries(ctx context.Context, filter model.AuditFilter, limit, offset int) ([]model.AuditEntry, error) {
+	query := `
+		SELECT ` + auditColumns + `
+		FROM audit_log
+		WHERE ($3 = '' OR tenant_id = $3)
+			AND ($4 = '' OR actor = $4)
+			AND ($5 = '' OR action = $5)
+			AND ($6 = '' OR target_type = $6)
+			AND ($7 = '' OR target_id = $7)
+			AND ($8::timestamp IS NULL OR created_at >= $8)
+			AND ($9::timestamp IS NULL OR created_at < $9)
+		ORDER BY id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := database.DB.Query(ctx, query, limit, offset,
+		filter.TenantID, filter.Actor, filter.Action, filter.TargetType, filter.TargetID,
+		filter.Since, filter.Until,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []model.AuditEntry{}
+	for rows.Next() {
+		var entry model.AuditEntry
+		if err := scanAuditEntry(rows, &entry); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// nullIfEmptyJSON stores missing values and JSON null as SQL NULL.
+func nullIfEmptyJSON(value []byte) any {
+	if len(value) == 0 || string(value) == "null" {
+		return nil
+	}
+	return string(value)
+}
+
+func scanAuditEntry(row pgx.Row, entry *model.AuditEntry) error {
+	var before, after []byte
+	err := row.Scan(
+		&entry.ID,
+		&entry.TenantID,
+		&entry.Actor,
+		&entry.Action,
+		&entry.TargetType,
+		&entry.TargetID,
+		&before,
+		&after,
+		&entry.CreatedAt,
+	)
+	entry.Before = before
+	entry.After = after
+	return err
+}
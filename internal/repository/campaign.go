@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+var (
+	ErrCampaignNotFound = errors.New("campaign not found")
+	// ErrCampaignTransition is returned when a campaign cannot move to the
+	// requested status, e.g. resuming a cancelled campaign.
+	ErrCampaignTransition = errors.New("invalid campaign status transition")
+)
+
+const campaignColumns = `id, tenant_id, name, template_id, channel, priority, status, created_at, updated_at`
+
+// campaignTransitions lists, for every target status, the statuses a campaign
+// may be in to move to it.
+var campaignTransitions = map[model.CampaignStatus][]string{
+	model.CampaignPaused:    {string(model.CampaignRunning)},
+	model.CampaignRunning:   {string(model.CampaignPaused)},
+	model.CampaignCancelled: {string(model.CampaignRunning), string(model.CampaignPaused)},
+}
+
+// campaignCancelledReason is the status_reason of messages cancelled with
+// their campaign.
+const campaignCancelledReason = "campaign cancelled"
+
+type CampaignRepository struct{}
+
+func NewCampaignRepository() *CampaignRepository {
+	return &CampaignRepository{}
+}
+
+// CreateCampaign inserts the campaign and its messages in one transaction.
+// Duplicates are skipped and reported as for MessageRepository.CreateMessages.
+func (r *CampaignRepository) CreateCampaign(
+	ctx context.Context,
+	campaign *model.Campaign,
+	messages []*model.Message,
+	dedupWindow time.Duration,
+) (map[int]int, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO campaigns (name, template_id, channel, priority, tenant_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + campaignColumns + `
+	`
+
+	err = scanCampaign(tx.QueryRow(ctx, query,
+		campaign.Name, campaign.TemplateID, campaign.Channel, campaign.Priority, campaign.TenantID,
+	), campaign)
+	if isForeignKeyViolation(err) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create campaign: %w", err)
+	}
+
+	for _, msg := range messages {
+		msg.CampaignID = &campaign.ID
+		msg.TenantID = campaign.TenantID
+	}
+
+	duplicates, err := insertMessages(ctx, tx, messages, dedupWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit campaign: %w", err)
+	}
+
+	return duplicates, nil
+}
+
+// GetCampaign returns the campaign with id if it belongs to tenantID.
+func (r *CampaignRepository) GetCampaign(ctx context.Context, tenantID string, id int) (*model.Campaign, error) {
+	query := `
+		SELECT ` + campaignColumns + `
+		FROM campaigns
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	var campaign model.Campaign
+	err := scanCampaign(database.DB.QueryRow(ctx, query, id, tenantID), &campaign)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCampaignNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign: %w", err)
+	}
+
+	return &campaign, nil
+}
+
+// ListCampaigns returns the campaigns of tenantID newest first.
+func (r *CampaignRepository) ListCampaigns(ctx context.Context, tenantID string, limit, offset int) ([]model.Campaign, error) {
+	query := `
+		SELECT ` + campaignColumns + `
+		FROM campaigns
+		WHERE tenant_id = $3
+		ORDER BY id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := database.DB.Query(ctx, query, limit, offset, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var campaigns []model.Campaign
+	for rows.Next() {
+		var campaign model.Campaign
+		if err := scanCampaign(rows, &campaign); err != nil {
+			return nil, fmt.Errorf("failed to scan campaign: %w", err)
+		}
+		campaigns = append(campaigns, campaign)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaigns: %w", err)
+	}
+
+	return campaigns, nil
+}
+
+// UpdateCampaignStatus moves the campaign of tenantID to status. Cancelling a
+// campaign also cancels its unsent messages. Messages already being sent
+// finish, but are cancelled instead of returned to unsent if the send fails
+// or is deferred, see OutboxRepository.
+func (r *CampaignRepository) UpdateCampaignStatus(
+	ctx context.Context,
+	tenantID string,
+	id int,
+	status model.CampaignStatus,
+) (*model.Campaign, error) {
+	from, ok := campaignTransitions[status]
+	if !ok {
+		return nil, fmt.Errorf("invalid campaign status: %s", status)
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE campaigns
+		SET status = $2
+		WHERE id = $1 AND status = ANY($3) AND tenant_id = $4
+		RETURNING ` + campaignColumns + `
+	`
+
+	var campaign model.Campaign
+	err = scanCampaign(tx.QueryRow(ctx, query, id, status, from, tenantID), &campaign)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM campaigns WHERE id = $1 AND tenant_id = $2)`, id, tenantID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("failed to get campaign: %w", err)
+		}
+		if !exists {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, ErrCampaignTransition
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update campaign status: %w", err)
+	}
+
+	if status == model.CampaignCancelled {
+		_, err := tx.Exec(ctx, `
+			UPDATE messages
+			SET status = 'cancelled', status_reason = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE campaign_id = $1 AND status = 'unsent'
+		`, id, campaignCancelledReason)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cancel campaign messages: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit campaign status: %w", err)
+	}
+
+	return &campaign, nil
+}
+
+// GetCampaignStats counts the campaign's messages by status and summarises
+// when they were sent.
+func (r *CampaignRepository) GetCampaignStats(ctx context.Context, id int) (*model.CampaignStats, error) {
+	stats := model.CampaignStats{ByStatus: map[model.MessageStatus]int{}}
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT status, COUNT(*)
+		FROM messages
+		WHERE campaign_id = $1
+		GROUP BY status
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count campaign messages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status model.MessageStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan campaign message count: %w", err)
+		}
+		stats.ByStatus[status] = count
+		stats.Total += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign message counts: %w", err)
+	}
+
+	err = database.DB.QueryRow(ctx, `
+		SELECT COUNT(sent_at), MIN(sent_at), MAX(sent_at),
+			COUNT(*) FILTER (WHERE sent_at >= CURRENT_TIMESTAMP - INTERVAL '1 hour')
+		FROM messages
+		WHERE campaign_id = $1
+	`, id).Scan(&stats.Sent, &stats.FirstSentAt, &stats.LastSentAt, &stats.SentLastHour)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarise campaign sends: %w", err)
+	}
+
+	return &stats, nil
+}
+
+func scanCampaign(row pgx.Row, campaign *model.Campaign) error {
+	return row.Scan(
+		&campaign.ID,
+		&campaign.TenantID,
+		&campaign.Name,
+		&campaign.TemplateID,
+		&campaign.Channel,
+		&campaign.Priority,
+		&campaign.Status,
+		&campaign.CreatedAt,
+		&campaign.UpdatedAt,
+	)
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+var ErrContactNotFound = errors.New("contact not found")
+
+const contactColumns = `id, tenant_id, recipient, name, COALESCE(locale, ''), COALESCE(timezone, ''), attributes, tags, created_at, updated_at`
+
+// segmentCondition selects contacts matching the segment in $1 (all tags),
+// $2 (any tag) and $3 (excluded tags). NULL arrays do not restrict.
+const segmentCondition = `($1::text[] IS NULL OR tags @> $1)
+	AND ($2::text[] IS NULL OR tags && $2)
+	AND ($3::text[] IS NULL OR NOT tags && $3)`
+
+type ContactRepository struct{}
+
+func NewContactRepository() *ContactRepository {
+	return &ContactRepository{}
+}
+
+// UpsertContact adds the contact or replaces the attributes of the tenant's
+// contact with the same recipient.
+func (r *ContactRepository) UpsertContact(ctx context.Context, contact *model.Contact) error {
+	if err := upsertContact(ctx, database.DB, contact); err != nil {
+		return fmt.Errorf("failed to save contact: %w", err)
+	}
+	return nil
+}
+
+// ImportContacts upserts all contacts in one transaction.
+func (r *ContactRepository) ImportContacts(ctx context.Context, contacts []*model.Contact) error {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, contact := range contacts {
+		if err := upsertContact(ctx, tx, contact); err != nil {
+			return fmt.Errorf("failed to import contact: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit contacts: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ContactRepository) GetContact(ctx context.Context, tenantID string, id int) (*model.Contact, error) {
+	query := `
+		SELECT ` + contactColumns + `
+		FROM contacts
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	var contact model.Contact
+	err := scanContact(database.DB.QueryRow(ctx, query, id, tenantID), &contact)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrContactNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact: %w", err)
+	}
+
+	return &contact, nil
+}
+
+// FindContacts returns the tenant's contacts in segment, oldest first.
+func (r *ContactRepository) FindContacts(ctx context.Context, tenantID string, segment model.Segment, limit, offset int) ([]model.Contact, error) {
+	query := `
+		SELECT ` + contactColumns + `
+		FROM contacts
+		WHERE tenant_id = $4 AND ` + segmentCondition + `
+		ORDER BY id ASC
+		LIMIT $5 OFFSET $6
+	`
+
+	rows, err := database.DB.Query(ctx, query,
+		nilIfEmpty(segment.Tags), nilIfEmpty(segment.AnyTags), nilIfEmpty(segment.ExcludeTags), tenantID, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contacts: %w", err)
+	}
+	defer rows.Close()
+
+	var contacts []model.Contact
+	for rows.Next() {
+		var contact model.Contact
+		if err := scanContact(rows, &contact); err != nil {
+			return nil, fmt.Errorf("failed to scan contact: %w", err)
+		}
+		contacts = append(contacts, contact)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating contacts: %w", err)
+	}
+
+	return contacts, nil
+}
+
+func (r *ContactRepository) DeleteContact(ctx context.Context, tenantID string, id int) error {
+	tag, err := database.DB.Exec(ctx, `DELETE FROM contacts WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete contact: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrContactNotFound
+	}
+
+	return nil
+}
+
+func upsertContact(ctx context.Context, q querier, contact *model.Contact) error {
+	query := `
+		INSERT INTO contacts (tenant_id, recipient, name, locale, timezone, attributes, tags)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7)
+		ON CONFLICT (tenant_id, recipient) DO UPDATE SET
+			name = EXCLUDED.name,
+			locale = EXCLUDED.locale,
+			timezone = EXCLUDED.timezone,
+			attributes = EXCLUDED.attributes,
+			tags = EXCLUDED.tags
+		RETURNING ` + contactColumns + `
+	`
+
+	attributes := contact.Attributes
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	tags := contact.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return scanContact(q.QueryRow(ctx, query,
+		contact.TenantID, contact.Recipient, contact.Name, contact.Locale, contact.Timezone, attributes, tags,
+	), contact)
+}
+
+func scanContact(row pgx.Row, contact *model.Contact) error {
+	return row.Scan(
+		&contact.ID,
+		&contact.TenantID,
+		&contact.Recipient,
+		&contact.Name,
+		&contact.Locale,
+		&contact.Timezone,
+		&contact.Attributes,
+		&contact.Tags,
+		&contact.CreatedAt,
+		&contact.UpdatedAt,
+	)
+}
+
+// nilIfEmpty makes empty slices encode as NULL.
+func nilIfEmpty(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
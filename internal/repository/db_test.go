@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/kubilayrn/ChronoGo/internal/database"
+)
+
+// testDatabaseURLEnv names a Postgres database the repository tests may
+// create schemas in. The tests are skipped without it.
+const testDatabaseURLEnv = "TEST_DATABASE_URL"
+
+// useTestDB points database.DB at a new schema with every migration applied,
+// dropped again when the test ends.
+func useTestDB(t *testing.T) {
+	t.Helper()
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+	ctx := context.Background()
+
+	admin, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", testDatabaseURLEnv, err)
+	}
+	t.Cleanup(admin.Close)
+
+	schema := fmt.Sprintf("chronogo_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("failed to drop schema: %v", err)
+		}
+	})
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", testDatabaseURLEnv, err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	config.ConnConfig.RuntimeParams["timezone"] = "UTC"
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", testDatabaseURLEnv, err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrations, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.sql"))
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("failed to find migrations: %v", err)
+	}
+	sort.Strings(migrations)
+	for _, path := range migrations {
+		sql, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", path, err)
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			t.Fatalf("failed to apply %s: %v", filepath.Base(path), err)
+		}
+	}
+
+	previous := database.DB
+	database.DB = pool
+	t.Cleanup(func() { database.DB = previous })
+}
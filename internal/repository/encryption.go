@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/encryption"
+)
+
+// sealContent encrypts message or part content with the active key. Content
+// is stored as is, with a nil key ID, when encryption is not configured or
+// the content is empty, e.g. a templated message not rendered yet.
+func sealContent(content string) (string, *string, error) {
+	if encryption.Keys == nil || content == "" {
+		return content, nil, nil
+	}
+
+	sealed, keyID, err := encryption.Keys.Seal(content)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encrypt content: %w", err)
+	}
+	return sealed, &keyID, nil
+}
+
+// openContent reverses sealContent. Rows without a key ID hold plain text,
+// written before encryption was turned on.
+func openContent(content string, keyID *string) (string, error) {
+	if keyID == nil {
+		return content, nil
+	}
+	if encryption.Keys == nil {
+		return "", fmt.Errorf("content is encrypted with key %q, but no encryption keys are configured", *keyID)
+	}
+	return encryption.Keys.Open(content, *keyID)
+}
+
+// sealVariables encodes message variables as JSON and encrypts them with the
+// active key. Nil variables are stored as NULL, and the JSON as is when
+// encryption is not configured.
+func sealVariables(variables map[string]any) (*string, *string, error) {
+	if variables == nil {
+		return nil, nil, nil
+	}
+
+	data, err := json.Marshal(variables)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode variables: %w", err)
+	}
+	encoded := string(data)
+	if encryption.Keys == nil {
+		return &encoded, nil, nil
+	}
+
+	sealed, keyID, err := encryption.Keys.Seal(encoded)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt variables: %w", err)
+	}
+	return &sealed, &keyID, nil
+}
+
+// openVariables reverses sealVariables.
+func openVariables(variables, keyID *string) (map[string]any, error) {
+	if variables == nil {
+		return nil, nil
+	}
+
+	encoded, err := openContent(*variables, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode variables: %w", err)
+	}
+	return decoded, nil
+}
+
+// ReencryptContent brings the content and variables of the next limit
+// messages after afterID, and the content of their parts, under the active
+// key: plain text is encrypted and values sealed with an older key have their
+// data key rewrapped. It returns the last message id visited, 0 once there
+// are none left, and how many rows were rewritten.
+func (r *MessageRepository) ReencryptContent(ctx context.Context, afterID, limit int) (int, int, error) {
+	return reencryptMessages(ctx, "messages", "message_parts", afterID, limit)
+}
+
+// ReencryptArchivedContent is ReencryptContent for messages moved to
+// messages_archive by retention, and their parts, so no key still protects
+// archived rows once rotation has finished.
+func (r *MessageRepository) ReencryptArchivedContent(ctx context.Context, afterID, limit int) (int, int, error) {
+	return reencryptMessages(ctx, "messages_archive", "message_parts_archive", afterID, limit)
+}
+
+// reencryptMessages re-encrypts a batch of table, which is messages or its
+// archive, and of their parts in partsTable.
+func reencryptMessages(ctx context.Context, table, partsTable string, afterID, limit int) (int, int, error) {
+	if encryption.Keys == nil {
+		return 0, 0, errors.New("no encryption keys are configured")
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin re-encryption transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the rows keeps the scheduler from writing content that this
+	// batch would then overwrite with its stale copy.
+	rows, err := tx.Query(ctx, `
+		SELECT id, content, content_key_id, variables, variables_key_id
+		FROM `+table+`
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE
+	`, afterID, limit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query %s to re-encrypt: %w", table, err)
+	}
+
+	var ids, rewriteIDs []int
+	var rewriteContent []string
+	var rewriteVariables, rewriteContentKeyIDs, rewriteVariablesKeyIDs []*string
+	for rows.Next() {
+		var id int
+		var content string
+		var contentKeyID, variables, variablesKeyID *string
+		if err := rows.Scan(&id, &content, &contentKeyID, &variables, &variablesKeyID); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan message: %w", err)
+		}
+		ids = append(ids, id)
+
+		sealed, contentChanged, err := reencrypt(content, contentKeyID)
+		if err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to re-encrypt message %d: %w", id, err)
+		}
+		if contentChanged {
+			content, contentKeyID = sealed, activeKeyID()
+		}
+
+		variablesChanged := false
+		if variables != nil {
+			sealed, changed, err := reencrypt(*variables, variablesKeyID)
+			if err != nil {
+				rows.Close()
+				return 0, 0, fmt.Errorf("failed to re-encrypt variables of message %d: %w", id, err)
+			}
+			if changed {
+				variables, variablesKeyID, variablesChanged = &sealed, activeKeyID(), true
+			}
+		}
+
+		if contentChanged || variablesChanged {
+			rewriteIDs = append(rewriteIDs, id)
+			rewriteContent = append(rewriteContent, content)
+			rewriteContentKeyIDs = append(rewriteContentKeyIDs, contentKeyID)
+			rewriteVariables = append(rewriteVariables, variables)
+			rewriteVariablesKeyIDs = append(rewriteVariablesKeyIDs, variablesKeyID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("error iterating %s: %w", table, err)
+	}
+	if len(ids) == 0 {
+		return 0, 0, nil
+	}
+
+	if len(rewriteIDs) > 0 {
+		_, err = tx.Exec(ctx, `
+			UPDATE `+table+` AS m
+			SET content = v.content, content_key_id = v.content_key_id,
+				variables = v.variables, variables_key_id = v.variables_key_id
+			FROM unnest($1::int[], $2::text[], $3::text[], $4::text[], $5::text[])
+				AS v(id, content, content_key_id, variables, variables_key_id)
+			WHERE m.id = v.id
+		`, rewriteIDs, rewriteContent, rewriteContentKeyIDs, rewriteVariables, rewriteVariablesKeyIDs)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to re-encrypt %s: %w", table, err)
+		}
+	}
+
+	parts, err := reencryptParts(ctx, tx, partsTable, ids)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit re-encryption: %w", err)
+	}
+
+	return ids[len(ids)-1], len(rewriteIDs) + parts, nil
+}
+
+func reencryptParts(ctx context.Context, tx pgx.Tx, table string, messageIDs []int) (int, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT message_id, number, content, content_key_id
+		FROM `+table+`
+		WHERE message_id = ANY($1)
+		FOR UPDATE
+	`, messageIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query message parts to re-encrypt: %w", err)
+	}
+	defer rows.Close()
+
+	var rewriteIDs, rewriteNumbers []int
+	var rewriteContent []string
+	for rows.Next() {
+		var messageID, number int
+		var content string
+		var keyID *string
+		if err := rows.Scan(&messageID, &number, &content, &keyID); err != nil {
+			return 0, fmt.Errorf("failed to scan message part: %w", err)
+		}
+
+		sealed, changed, err := reencrypt(content, keyID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to re-encrypt part %d of message %d: %w", number, messageID, err)
+		}
+		if changed {
+			rewriteIDs = append(rewriteIDs, messageID)
+			rewriteNumbers = append(rewriteNumbers, number)
+			rewriteContent = append(rewriteContent, sealed)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating message parts: %w", err)
+	}
+	rows.Close()
+	if len(rewriteIDs) == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE `+table+` AS p
+		SET content = v.content, content_key_id = $4
+		FROM unnest($1::int[], $2::int[], $3::text[]) AS v(message_id, number, content)
+		WHERE p.message_id = v.message_id AND p.number = v.number
+	`, rewriteIDs, rewriteNumbers, rewriteContent, encryption.Keys.ActiveKeyID())
+	if err != nil {
+		return 0, fmt.Errorf("failed to re-encrypt message parts: %w", err)
+	}
+
+	return len(rewriteIDs), nil
+}
+
+// activeKeyID returns the ID of the active key, for storing next to values
+// sealed with it.
+func activeKeyID() *string {
+	keyID := encryption.Keys.ActiveKeyID()
+	return &keyID
+}
+
+// reencrypt returns content sealed with the active key, and whether that
+// differs from what is stored. Empty content is left as it is.
+func reencrypt(content string, keyID *string) (string, bool, error) {
+	if content == "" {
+		return content, false, nil
+	}
+	if keyID == nil {
+		sealed, _, err := encryption.Keys.Seal(content)
+		return sealed, true, err
+	}
+	if *keyID == encryption.Keys.ActiveKeyID() {
+		return content, false, nil
+	}
+	rewrapped, _, err := encryption.Keys.Rewrap(content, *keyID)
+	return rewrapped, true, err
+}
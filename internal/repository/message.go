@@ -2,132 +2,389 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kubilayrn/ChronoGo/internal/database"
 	"github.com/kubilayrn/ChronoGo/internal/model"
 )
 
+var ErrMessageNotFound = errors.New("message not found")
+
+const messageColumns = `id, tenant_id, "to", channel, content, content_key_id, status, status_reason, priority, template_id, variables, variables_key_id, COALESCE(locale, ''), COALESCE(timezone, ''), critical, send_after, COALESCE(dedup_key, ''), campaign_id, COALESCE(created_by, ''), sent_at, message_id, delivered_at, read_at, created_at, updated_at`
+
+// sendableNow excludes unsent messages deferred to a later time, and messages
+// of campaigns that are not running. send_after is stored in UTC.
+const sendableNow = `(send_after IS NULL OR send_after <= (CURRENT_TIMESTAMP AT TIME ZONE 'UTC'))
+	AND (campaign_id IS NULL OR NOT EXISTS (
+		SELECT 1 FROM campaigns WHERE campaigns.id = messages.campaign_id AND campaigns.status <> 'running'
+	))`
+
+// deliveryTransitions lists, for every receipt status, the statuses a message may
+// be in for the receipt to apply. Receipts can arrive out of order, so a late
+// "delivered" must not overwrite a "read".
+var deliveryTransitions = map[model.MessageStatus][]string{
+	model.StatusDelivered:   {string(model.StatusSent), string(model.StatusUndelivered)},
+	model.StatusUndelivered: {string(model.StatusSent)},
+	model.StatusRead:        {string(model.StatusSent), string(model.StatusDelivered), string(model.StatusUndelivered)},
+}
+
 type MessageRepository struct{}
 
 func NewMessageRepository() *MessageRepository {
 	return &MessageRepository{}
 }
 
-func (r *MessageRepository) GetUnsentMessages(ctx context.Context, limit int) ([]model.Message, error) {
+// DuplicateError is returned when a message with the same recipient and dedup
+// key was accepted within the deduplication window.
+type DuplicateError struct {
+	// MessageID is the earlier message, or 0 if it no longer exists.
+	MessageID int
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("duplicate of message %d", e.MessageID)
+}
+
+// CreateMessage inserts msg. See CreateMessages for dedupWindow.
+func (r *MessageRepository) CreateMessage(ctx context.Context, msg *model.Message, dedupWindow time.Duration) error {
+	duplicates, err := r.CreateMessages(ctx, []*model.Message{msg}, dedupWindow)
+	if err != nil {
+		return err
+	}
+	if existing, ok := duplicates[0]; ok {
+		return &DuplicateError{MessageID: existing}
+	}
+	return nil
+}
+
+// CreateMessages inserts all messages in one transaction, so either every
+// message is queued or none is. When dedupWindow is positive, a message whose
+// recipient and DedupKey were accepted within the window is not inserted;
+// such messages are returned as their index mapped to the earlier message id.
+func (r *MessageRepository) CreateMessages(
+	ctx context.Context,
+	messages []*model.Message,
+	dedupWindow time.Duration,
+) (map[int]int, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	duplicates, err := insertMessages(ctx, tx, messages, dedupWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit messages: %w", err)
+	}
+
+	return duplicates, nil
+}
+
+// insertMessages inserts messages in tx, skipping duplicates as described for
+// CreateMessages.
+func insertMessages(ctx context.Context, tx pgx.Tx, messages []*model.Message, dedupWindow time.Duration) (map[int]int, error) {
+	duplicates := map[int]int{}
+	for i, msg := range messages {
+		deduplicate := dedupWindow > 0 && msg.DedupKey != ""
+		if deduplicate {
+			existing, duplicate, err := claimDedupKey(ctx, tx, msg, dedupWindow)
+			if err != nil {
+				return nil, err
+			}
+			if duplicate {
+				duplicates[i] = existing
+				continue
+			}
+		}
+
+		if err := insertMessage(ctx, tx, msg); err != nil {
+			return nil, fmt.Errorf("failed to create message: %w", err)
+		}
+
+		if deduplicate {
+			_, err := tx.Exec(ctx, `
+				UPDATE message_dedup_keys SET message_id = $3
+				WHERE tenant_id = $4 AND recipient = $1 AND dedup_key = $2
+			`, msg.To, msg.DedupKey, msg.ID, msg.TenantID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to link dedup key: %w", err)
+			}
+		}
+	}
+
+	return duplicates, nil
+}
+
+// FindSentDuplicate returns another message of the same tenant to the same
+// recipient with the same dedup key that was sent within window.
+func (r *MessageRepository) FindSentDuplicate(ctx context.Context, msg model.Message, window time.Duration) (int, bool, error) {
 	query := `
-		SELECT id, "to", content, status, sent_at, message_id, created_at, updated_at
+		SELECT id
 		FROM messages
-		WHERE status = 'unsent'
-		ORDER BY created_at ASC
-		LIMIT $1
+		WHERE "to" = $1 AND dedup_key = $2 AND id <> $3 AND tenant_id = $5
+			AND sent_at >= CURRENT_TIMESTAMP - $4::double precision * INTERVAL '1 second'
+		ORDER BY sent_at DESC
+		LIMIT 1
 	`
 
-	rows, err := database.DB.Query(ctx, query, limit)
+	var id int
+	err := database.DB.QueryRow(ctx, query, msg.To, msg.DedupKey, msg.ID, window.Seconds(), msg.TenantID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to query unsent messages: %w", err)
+		return 0, false, fmt.Errorf("failed to look up duplicate: %w", err)
 	}
-	defer rows.Close()
 
-	var messages []model.Message
-	for rows.Next() {
-		var msg model.Message
-		var sentAt pgtype.Timestamp
-		var messageID *uuid.UUID
-
-		err := rows.Scan(
-			&msg.ID,
-			&msg.To,
-			&msg.Content,
-			&msg.Status,
-			&sentAt,
-			&messageID,
-			&msg.CreatedAt,
-			&msg.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan message: %w", err)
-		}
+	return id, true, nil
+}
 
-		if sentAt.Valid {
-			msg.SentAt = &sentAt.Time
-		}
-		msg.MessageID = messageID
+// GetMessageByID returns the message with id if it belongs to tenantID.
+func (r *MessageRepository) GetMessageByID(ctx context.Context, tenantID string, id int) (*model.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE id = $1 AND tenant_id = $2
+	`
 
-		messages = append(messages, msg)
+	msg, err := scanMessage(database.DB.QueryRow(ctx, query, id, tenantID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating messages: %w", err)
+	return msg, nil
+}
+
+// GetMessageByProviderID returns the message the provider knows as messageID,
+// which may also be the id of one of its parts. With webhookTenant set only
+// messages of that tenant, sent through its own webhook, are matched; without
+// it only messages of tenants sending through the shared webhook are, so one
+// provider cannot report on another provider's messages.
+func (r *MessageRepository) GetMessageByProviderID(
+	ctx context.Context,
+	webhookTenant string,
+	messageID uuid.UUID,
+) (*model.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE (message_id = $1 OR id IN (SELECT message_id FROM message_parts WHERE provider_message_id = $1))
+			AND CASE WHEN $2::text = '' THEN NOT EXISTS (
+					SELECT 1 FROM tenants WHERE tenants.id = messages.tenant_id AND tenants.webhook_url <> ''
+				) ELSE tenant_id = $2 END
+		LIMIT 1
+	`
+
+	msg, err := scanMessage(database.DB.QueryRow(ctx, query, messageID, webhookTenant))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message by provider id: %w", err)
 	}
 
-	return messages, nil
+	return msg, nil
 }
 
-func (r *MessageRepository) UpdateMessageStatus(
+// UpdateDeliveryStatus applies a delivery receipt to the message. It returns
+// false when the message's current status does not allow the transition.
+func (r *MessageRepository) UpdateDeliveryStatus(
 	ctx context.Context,
 	id int,
 	status model.MessageStatus,
-	messageID *uuid.UUID,
-	sentAt *time.Time,
-) error {
+	at time.Time,
+) (bool, error) {
+	from, ok := deliveryTransitions[status]
+	if !ok {
+		return false, fmt.Errorf("invalid delivery status: %s", status)
+	}
+
 	query := `
 		UPDATE messages
-		SET status = $1, message_id = $2, sent_at = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $4
+		SET status = $1,
+			delivered_at = CASE WHEN $1::varchar = 'delivered' THEN $2 ELSE delivered_at END,
+			read_at = CASE WHEN $1::varchar = 'read' THEN $2 ELSE read_at END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = ANY($4)
 	`
 
-	_, err := database.DB.Exec(ctx, query, status, messageID, sentAt, id)
+	tag, err := database.DB.Exec(ctx, query, status, at, id, from)
 	if err != nil {
-		return fmt.Errorf("failed to update message status: %w", err)
+		return false, fmt.Errorf("failed to update delivery status: %w", err)
 	}
 
-	return nil
+	return tag.RowsAffected() > 0, nil
 }
 
-func (r *MessageRepository) GetSentMessages(ctx context.Context) ([]model.Message, error) {
+// GetSentMessages returns the sent messages of tenantID, most recent first.
+func (r *MessageRepository) GetSentMessages(ctx context.Context, tenantID string) ([]model.Message, error) {
 	query := `
-		SELECT id, "to", content, status, sent_at, message_id, created_at, updated_at
+		SELECT ` + messageColumns + `
 		FROM messages
-		WHERE status = 'sent'
+		WHERE tenant_id = $1 AND status IN ('sent', 'delivered', 'undelivered', 'read')
 		ORDER BY sent_at DESC
 	`
 
-	rows, err := database.DB.Query(ctx, query)
+	rows, err := database.DB.Query(ctx, query, tenantID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query sent messages: %w", err)
 	}
+
+	return collectMessages(rows)
+}
+
+// querier is implemented by both the pool and transactions.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertMessage(ctx context.Context, q querier, msg *model.Message) error {
+	query := `
+		INSERT INTO messages (
+			"to", channel, content, status, status_reason, priority,
+			template_id, variables, locale, timezone, critical, dedup_key, campaign_id, created_by,
+			tenant_id, content_key_id, variables_key_id
+		)
+		VALUES (
+			$1, $2, $3, COALESCE(NULLIF($4, ''), 'unsent'), $5, $6,
+			$7, $8, NULLIF($9, ''), NULLIF($10, ''), $11, NULLIF($12, ''), $13, NULLIF($14, ''),
+			COALESCE(NULLIF($15, ''), 'default'), $16, $17
+		)
+		RETURNING ` + messageColumns + `
+	`
+
+	content, keyID, err := sealContent(msg.Content)
+	if err != nil {
+		return err
+	}
+	variables, variablesKeyID, err := sealVariables(msg.Variables)
+	if err != nil {
+		return err
+	}
+
+	created, err := scanMessage(q.QueryRow(ctx, query,
+		msg.To, msg.Channel, content, msg.Status, msg.StatusReason,
+		msg.Priority, msg.TemplateID, variables, msg.Locale, msg.Timezone, msg.Critical, msg.DedupKey,
+		msg.CampaignID, msg.CreatedBy, msg.TenantID, keyID, variablesKeyID,
+	))
+	if err != nil {
+		return err
+	}
+
+	*msg = *created
+	return nil
+}
+
+// claimDedupKey takes the recipient and key of msg for window. It reports a
+// duplicate, with the id of the message holding the key, when the key is
+// taken and has not expired. Concurrent claims serialise on the primary key.
+func claimDedupKey(ctx context.Context, tx pgx.Tx, msg *model.Message, window time.Duration) (int, bool, error) {
+	err := tx.QueryRow(ctx, `
+		INSERT INTO message_dedup_keys (tenant_id, recipient, dedup_key, expires_at)
+		VALUES ($4, $1, $2, CURRENT_TIMESTAMP + $3::double precision * INTERVAL '1 second')
+		ON CONFLICT (tenant_id, recipient, dedup_key) DO UPDATE
+		SET message_id = NULL, expires_at = EXCLUDED.expires_at
+		WHERE message_dedup_keys.expires_at <= CURRENT_TIMESTAMP
+		RETURNING recipient
+	`, msg.To, msg.DedupKey, window.Seconds(), msg.TenantID).Scan(new(string))
+	if err == nil {
+		return 0, false, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, fmt.Errorf("failed to claim dedup key: %w", err)
+	}
+
+	var existing int
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(message_id, 0) FROM message_dedup_keys
+		WHERE tenant_id = $3 AND recipient = $1 AND dedup_key = $2
+	`, msg.To, msg.DedupKey, msg.TenantID).Scan(&existing)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read dedup key: %w", err)
+	}
+
+	return existing, true, nil
+}
+
+// scanMessage reads a single row selected with messageColumns, decrypting
+// its content.
+func scanMessage(row pgx.Row) (*model.Message, error) {
+	var msg model.Message
+	var contentKeyID, variables, variablesKeyID *string
+	var sentAt pgtype.Timestamp
+	var messageID *uuid.UUID
+
+	err := row.Scan(
+		&msg.ID,
+		&msg.TenantID,
+		&msg.To,
+		&msg.Channel,
+		&msg.Content,
+		&contentKeyID,
+		&msg.Status,
+		&msg.StatusReason,
+		&msg.Priority,
+		&msg.TemplateID,
+		&variables,
+		&variablesKeyID,
+		&msg.Locale,
+		&msg.Timezone,
+		&msg.Critical,
+		&msg.SendAfter,
+		&msg.DedupKey,
+		&msg.CampaignID,
+		&msg.CreatedBy,
+		&sentAt,
+		&messageID,
+		&msg.DeliveredAt,
+		&msg.ReadAt,
+		&msg.CreatedAt,
+		&msg.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if sentAt.Valid {
+		msg.SentAt = &sentAt.Time
+	}
+	msg.MessageID = messageID
+
+	msg.Content, err = openContent(msg.Content, contentKeyID)
+	if err != nil {
+		return nil, err
+	}
+	msg.Variables, err = openVariables(variables, variablesKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+func collectMessages(rows pgx.Rows) ([]model.Message, error) {
 	defer rows.Close()
 
 	var messages []model.Message
 	for rows.Next() {
-		var msg model.Message
-		var sentAt pgtype.Timestamp
-		var messageID *uuid.UUID
-
-		err := rows.Scan(
-			&msg.ID,
-			&msg.To,
-			&msg.Content,
-			&msg.Status,
-			&sentAt,
-			&messageID,
-			&msg.CreatedAt,
-			&msg.UpdatedAt,
-		)
+		msg, err := scanMessage(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
 
-		if sentAt.Valid {
-			msg.SentAt = &sentAt.Time
-		}
-		msg.MessageID = messageID
-
-		messages = append(messages, msg)
+		messages = append(messages, *msg)
 	}
 
 	if err := rows.Err(); err != nil {
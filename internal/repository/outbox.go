@@ -0,0 +1,562 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+// claimExpiredError is stored on attempts that were still open when their claim
+// expired, e.g. because the process died between sending and finalising.
+const claimExpiredError = "claim expired before the attempt was finalised"
+
+// inCancelledCampaign matches messages of a cancelled campaign. Cancelling
+// only cancels unsent messages, so messages claimed at the time are cancelled
+// when they would otherwise go back to unsent, where the scheduler would
+// never pick them again.
+const inCancelledCampaign = `EXISTS (
+	SELECT 1 FROM campaigns WHERE campaigns.id = messages.campaign_id AND campaigns.status = 'cancelled'
+)`
+
+// ClaimOptions controls which messages ClaimMessages picks.
+type ClaimOptions struct {
+	Limit int
+	// ReclaimBefore, when set, makes messages whose claim is older than it
+	// claimable again, which is what gives at-least-once delivery after a crash.
+	ReclaimBefore *time.Time
+	// PriorityAging is how long a message waits before it gains one priority
+	// level. Zero means strict priority order.
+	PriorityAging time.Duration
+}
+
+// Claim is a message the scheduler owns until it is finalised, together with
+// the attempt row opened for it.
+type Claim struct {
+	Message   model.Message
+	AttemptID int
+}
+
+// OutboxRepository drives the delivery state machine:
+//
+//	unsent --claim--> processing --complete--> sent
+//	                  processing --fail------> unsent | failed
+//	                  processing --skip------> suppressed | duplicate
+//	                  processing --defer-----> unsent (send_after)
+//
+// Messages of a cancelled campaign go to cancelled instead of unsent.
+//
+// A send the provider accepted is recorded with RecordSentAttempt before the
+// message is completed. A claim that expires after that, e.g. because
+// completing failed, is settled as sent with the recorded provider id rather
+// than sent again or failed.
+//
+// Messages sent in parts record each part with RecordPart as it is sent and
+// only complete once every part has been sent.
+//
+// Every transition runs in a single transaction together with the matching
+// message_attempts change, so the attempt history and the message row never
+// disagree.
+type OutboxRepository struct{}
+
+func NewOutboxRepository() *OutboxRepository {
+	return &OutboxRepository{}
+}
+
+// unsentOrder is the order in which unsent messages are handed to the scheduler:
+// highest priority first, oldest first within a priority.
+//
+// With a positive aging interval a message gains one priority level for every
+// interval it has waited, so a steady stream of urgent messages delays low
+// priority ones but cannot starve them forever.
+func unsentOrder(aging time.Duration) string {
+	seconds := int64(aging.Seconds())
+	if seconds <= 0 {
+		return `priority DESC, created_at ASC`
+	}
+	return fmt.Sprintf(
+		`priority + FLOOR(EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - created_at)) / %d) DESC, created_at ASC`,
+		seconds,
+	)
+}
+
+// ClaimMessages moves up to opts.Limit unsent messages to processing and opens
+// an attempt for each.
+//
+// The batch is shared fairly between tenants: each tenant's messages are
+// ranked in priority order and the batch takes every tenant's first message
+// before any tenant's second, so one tenant's backlog cannot starve the others.
+// A tenant's batch_limit caps how many of its messages a batch takes.
+func (r *OutboxRepository) ClaimMessages(ctx context.Context, opts ClaimOptions) ([]Claim, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin claim transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if opts.ReclaimBefore != nil {
+		if err := settleExpiredClaims(ctx, tx, *opts.ReclaimBefore); err != nil {
+			return nil, err
+		}
+		if err := cancelExpiredClaims(ctx, tx, *opts.ReclaimBefore); err != nil {
+			return nil, err
+		}
+	}
+
+	order := unsentOrder(opts.PriorityAging)
+	claimable := `((status = 'unsent' AND ` + sendableNow + `)
+			OR (status = 'processing' AND $2::timestamp IS NOT NULL AND claimed_at < $2))`
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE ` + claimable + `
+			AND id IN (
+				SELECT ranked.id
+				FROM (
+					SELECT id, tenant_id,
+						ROW_NUMBER() OVER (PARTITION BY tenant_id ORDER BY ` + order + `) AS tenant_rank,
+						ROW_NUMBER() OVER (ORDER BY ` + order + `) AS overall_rank
+					FROM messages
+					WHERE ` + claimable + `
+				) ranked
+				JOIN tenants ON tenants.id = ranked.tenant_id
+				WHERE tenants.batch_limit IS NULL OR ranked.tenant_rank <= tenants.batch_limit
+				ORDER BY ranked.tenant_rank, ranked.overall_rank
+				LIMIT $1
+			)
+		ORDER BY ` + order + `
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := tx.Query(ctx, query, opts.Limit, opts.ReclaimBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query claimable messages: %w", err)
+	}
+
+	messages, err := collectMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE messages
+		SET status = 'processing', claimed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($1)
+	`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim messages: %w", err)
+	}
+
+	if err := closeOpenAttempts(ctx, tx, ids); err != nil {
+		return nil, err
+	}
+
+	claims := make([]Claim, len(messages))
+	for i, msg := range messages {
+		msg.Status = model.StatusProcessing
+		claims[i].Message = msg
+
+		err := tx.QueryRow(ctx, `
+			INSERT INTO message_attempts (message_id, started_at)
+			VALUES ($1, CURRENT_TIMESTAMP)
+			RETURNING id
+		`, msg.ID).Scan(&claims[i].AttemptID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open attempt for message %d: %w", msg.ID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit claim: %w", err)
+	}
+
+	return claims, nil
+}
+
+// RecordSentAttempt closes the attempt of a send the provider accepted, with
+// its provider id, while the message stays claimed until CompleteAttempt.
+func (r *OutboxRepository) RecordSentAttempt(ctx context.Context, claim Claim, attempt *model.MessageAttempt) error {
+	_, err := database.DB.Exec(ctx, `
+		UPDATE message_attempts
+		SET finished_at = $1, status_code = $2, latency_ms = $3, response_body = $4,
+			provider_message_id = $5
+		WHERE id = $6
+	`,
+		attempt.FinishedAt,
+		attempt.StatusCode,
+		attempt.LatencyMs,
+		attempt.ResponseBody,
+		attempt.ProviderMessageID,
+		claim.AttemptID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record sent attempt: %w", err)
+	}
+	return nil
+}
+
+// CompleteAttempt closes the attempt and marks the claimed message as sent.
+// For templated messages the claim's Content, rendered at send time, is stored
+// as the message content.
+func (r *OutboxRepository) CompleteAttempt(ctx context.Context, claim Claim, attempt *model.MessageAttempt) error {
+	return r.finalise(ctx, claim, attempt, model.StatusSent, nil)
+}
+
+// FailAttempt closes the attempt and moves the claimed message to next, which
+// is StatusUnsent to retry on a later tick or StatusFailed to give up.
+func (r *OutboxRepository) FailAttempt(ctx context.Context, claim Claim, attempt *model.MessageAttempt, next model.MessageStatus) error {
+	return r.finalise(ctx, claim, attempt, next, nil)
+}
+
+// SkipMessage closes the attempt without the message having been sent and
+// moves the message to the terminal status with reason.
+func (r *OutboxRepository) SkipMessage(
+	ctx context.Context,
+	claim Claim,
+	attempt *model.MessageAttempt,
+	status model.MessageStatus,
+	reason string,
+) error {
+	return r.finalise(ctx, claim, attempt, status, &reason)
+}
+
+// DeferMessage returns the claimed message to unsent without attempting it
+// before until. The attempt opened by the claim is discarded as nothing was
+// attempted.
+func (r *OutboxRepository) DeferMessage(ctx context.Context, claim Claim, until time.Time) error {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin defer transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM message_attempts WHERE id = $1`, claim.AttemptID); err != nil {
+		return fmt.Errorf("failed to discard attempt: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE messages
+		SET status = CASE WHEN `+inCancelledCampaign+` THEN 'cancelled' ELSE 'unsent' END,
+			status_reason = CASE WHEN `+inCancelledCampaign+` THEN $3 ELSE status_reason END,
+			send_after = $1, claimed_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status = 'processing'
+	`, until.UTC(), claim.Message.ID, campaignCancelledReason)
+	if err != nil {
+		return fmt.Errorf("failed to defer message: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("message %d is no longer claimed", claim.Message.ID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit defer: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepository) finalise(
+	ctx context.Context,
+	claim Claim,
+	attempt *model.MessageAttempt,
+	next model.MessageStatus,
+	reason *string,
+) error {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin finalise transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE message_attempts
+		SET finished_at = $1, status_code = $2, latency_ms = $3, response_body = $4,
+			provider_message_id = $5, error = $6
+		WHERE id = $7
+	`,
+		attempt.FinishedAt,
+		attempt.StatusCode,
+		attempt.LatencyMs,
+		attempt.ResponseBody,
+		attempt.ProviderMessageID,
+		attempt.Error,
+		claim.AttemptID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finalise attempt: %w", err)
+	}
+
+	var sentAt *time.Time
+	var renderedContent, contentKeyID *string
+	if next == model.StatusSent {
+		sentAt = attempt.FinishedAt
+		if claim.Message.TemplateID != nil {
+			content, keyID, err := sealContent(claim.Message.Content)
+			if err != nil {
+				return err
+			}
+			renderedContent, contentKeyID = &content, keyID
+		}
+	}
+
+	// The status guard makes finalising a claim that was already taken over
+	// by another worker a no-op instead of clobbering its result.
+	retryCancelled := `$1::text = 'unsent' AND ` + inCancelledCampaign
+	tag, err := tx.Exec(ctx, `
+		UPDATE messages
+		SET status = CASE WHEN `+retryCancelled+` THEN 'cancelled' ELSE $1 END,
+			message_id = COALESCE($2, message_id), sent_at = COALESCE($3, sent_at),
+			content = COALESCE($4, content),
+			content_key_id = CASE WHEN $4::text IS NULL THEN content_key_id ELSE $7 END,
+			status_reason = CASE WHEN `+retryCancelled+` THEN $8 ELSE $5 END,
+			claimed_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6 AND status = 'processing'
+	`, next, attempt.ProviderMessageID, sentAt, renderedContent, reason, claim.Message.ID, contentKeyID, campaignCancelledReason)
+	if err != nil {
+		return fmt.Errorf("failed to finalise message: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("message %d is no longer claimed", claim.Message.ID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit finalise: %w", err)
+	}
+
+	return nil
+}
+
+// GetMessageParts returns the parts of a message recorded so far, keyed by
+// part number.
+func (r *OutboxRepository) GetMessageParts(ctx context.Context, messageID int) (map[int]model.MessagePart, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT `+partColumns+`
+		FROM message_parts
+		WHERE message_id = $1
+	`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query message parts: %w", err)
+	}
+
+	parts, err := collectParts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	byNumber := make(map[int]model.MessagePart, len(parts))
+	for _, part := range parts {
+		byNumber[part.Number] = part
+	}
+	return byNumber, nil
+}
+
+// RecordPart stores the outcome of sending a part, replacing an earlier
+// failed outcome of the same part.
+func (r *OutboxRepository) RecordPart(ctx context.Context, part model.MessagePart) error {
+	content, keyID, err := sealContent(part.Content)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.DB.Exec(ctx, `
+		INSERT INTO message_parts (message_id, number, total, content, content_key_id, status, provider_message_id, error, sent_at)
+		VALUES ($1, $2, $3, $4, $9, $5, $6, $7, $8)
+		ON CONFLICT (message_id, number) DO UPDATE SET
+			total = EXCLUDED.total,
+			content = EXCLUDED.content,
+			content_key_id = EXCLUDED.content_key_id,
+			status = EXCLUDED.status,
+			provider_message_id = EXCLUDED.provider_message_id,
+			error = EXCLUDED.error,
+			sent_at = EXCLUDED.sent_at
+	`,
+		part.MessageID,
+		part.Number,
+		part.Total,
+		content,
+		part.Status,
+		part.ProviderMessageID,
+		part.Error,
+		part.SentAt,
+		keyID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record message part: %w", err)
+	}
+	return nil
+}
+
+// FailExpiredClaims marks messages whose claim is older than before as failed
+// without sending them again. It is used for at-most-once delivery, where a
+// message that may already have reached the provider must never be resent.
+// Messages whose send was recorded are settled as sent instead.
+func (r *OutboxRepository) FailExpiredClaims(ctx context.Context, before time.Time) (int, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin expiry transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := settleExpiredClaims(ctx, tx, before); err != nil {
+		return 0, err
+	}
+	if err := cancelExpiredClaims(ctx, tx, before); err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE messages
+		SET status = 'failed', claimed_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'processing' AND claimed_at < $1
+		RETURNING id
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire claims: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect expired claims: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if err := closeOpenAttempts(ctx, tx, ids); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit expiry: %w", err)
+	}
+
+	return len(ids), nil
+}
+
+// GetBacklog returns how many messages are due to be sent but unclaimed, and
+// how long the oldest of them has been due.
+func (r *OutboxRepository) GetBacklog(ctx context.Context) (int, time.Duration, error) {
+	var count int
+	var oldestSeconds float64
+	err := database.DB.QueryRow(ctx, `
+		SELECT COUNT(*),
+			COALESCE(EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP AT TIME ZONE 'UTC') - MIN(COALESCE(send_after, created_at))), 0)::float8
+		FROM messages
+		WHERE status = 'unsent' AND `+sendableNow+`
+	`).Scan(&count, &oldestSeconds)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query backlog: %w", err)
+	}
+
+	return count, time.Duration(oldestSeconds * float64(time.Second)), nil
+}
+
+const partColumns = `message_id, number, total, content, content_key_id, status, provider_message_id, error, sent_at`
+
+func collectParts(rows pgx.Rows) ([]model.MessagePart, error) {
+	defer rows.Close()
+
+	parts := []model.MessagePart{}
+	for rows.Next() {
+		var part model.MessagePart
+		var contentKeyID *string
+		err := rows.Scan(
+			&part.MessageID,
+			&part.Number,
+			&part.Total,
+			&part.Content,
+			&contentKeyID,
+			&part.Status,
+			&part.ProviderMessageID,
+			&part.Error,
+			&part.SentAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message part: %w", err)
+		}
+		part.Content, err = openContent(part.Content, contentKeyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message part: %w", err)
+		}
+		parts = append(parts, part)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message parts: %w", err)
+	}
+
+	return parts, nil
+}
+
+// settleExpiredClaims marks messages whose claim is older than before as sent
+// when the latest attempt recorded a provider id, i.e. the provider accepted
+// the message but completing it did not finish.
+func settleExpiredClaims(ctx context.Context, tx pgx.Tx, before time.Time) error {
+	_, err := tx.Exec(ctx, `
+		WITH latest AS (
+			SELECT DISTINCT ON (message_attempts.message_id)
+				message_attempts.message_id, message_attempts.provider_message_id, message_attempts.finished_at
+			FROM message_attempts
+			JOIN messages ON messages.id = message_attempts.message_id
+			WHERE messages.status = 'processing' AND messages.claimed_at < $1
+			ORDER BY message_attempts.message_id, message_attempts.id DESC
+		)
+		UPDATE messages
+		SET status = 'sent', message_id = latest.provider_message_id,
+			sent_at = COALESCE(latest.finished_at, CURRENT_TIMESTAMP),
+			claimed_at = NULL, updated_at = CURRENT_TIMESTAMP
+		FROM latest
+		WHERE messages.id = latest.message_id AND latest.provider_message_id IS NOT NULL
+			AND messages.status = 'processing'
+	`, before)
+	if err != nil {
+		return fmt.Errorf("failed to settle expired claims: %w", err)
+	}
+	return nil
+}
+
+// cancelExpiredClaims cancels the messages of cancelled campaigns whose claim
+// is older than before, rather than sending them again or failing them.
+func cancelExpiredClaims(ctx context.Context, tx pgx.Tx, before time.Time) error {
+	rows, err := tx.Query(ctx, `
+		UPDATE messages
+		SET status = 'cancelled', status_reason = $2, claimed_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'processing' AND claimed_at < $1 AND `+inCancelledCampaign+`
+		RETURNING id
+	`, before, campaignCancelledReason)
+	if err != nil {
+		return fmt.Errorf("failed to cancel expired claims: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return fmt.Errorf("failed to collect cancelled claims: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return closeOpenAttempts(ctx, tx, ids)
+}
+
+func closeOpenAttempts(ctx context.Context, tx pgx.Tx, messageIDs []int) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE message_attempts
+		SET finished_at = CURRENT_TIMESTAMP, error = $2
+		WHERE message_id = ANY($1) AND finished_at IS NULL
+	`, messageIDs, claimExpiredError)
+	if err != nil {
+		return fmt.Errorf("failed to close expired attempts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,392 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+// messageRow is the part of a message row the state machine changes.
+type messageRow struct {
+	Status       model.MessageStatus
+	StatusReason *string
+	MessageID    *uuid.UUID
+	SentAt       *time.Time
+	SendAfter    *time.Time
+	ClaimedAt    *time.Time
+}
+
+func insertTestMessage(t *testing.T, campaignID *int) int {
+	t.Helper()
+	var id int
+	err := database.DB.QueryRow(context.Background(), `
+		INSERT INTO messages ("to", content, campaign_id) VALUES ('+905551234567', 'hello', $1) RETURNING id
+	`, campaignID).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to insert message: %v", err)
+	}
+	return id
+}
+
+func insertTestCampaign(t *testing.T) int {
+	t.Helper()
+	ctx := context.Background()
+	var templateID, campaignID int
+	if err := database.DB.QueryRow(ctx, `INSERT INTO templates (name, body) VALUES ('t', 'hi') RETURNING id`).Scan(&templateID); err != nil {
+		t.Fatalf("failed to insert template: %v", err)
+	}
+	err := database.DB.QueryRow(ctx, `
+		INSERT INTO campaigns (name, template_id) VALUES ('c', $1) RETURNING id
+	`, templateID).Scan(&campaignID)
+	if err != nil {
+		t.Fatalf("failed to insert campaign: %v", err)
+	}
+	return campaignID
+}
+
+// cancelTestCampaign cancels a campaign the way CancelCampaign does, after
+// its messages were claimed.
+func cancelTestCampaign(t *testing.T, id int) {
+	t.Helper()
+	if _, err := NewCampaignRepository().UpdateCampaignStatus(context.Background(), model.DefaultTenant, id, model.CampaignCancelled); err != nil {
+		t.Fatalf("failed to cancel campaign %d: %v", id, err)
+	}
+}
+
+func getMessageRow(t *testing.T, id int) messageRow {
+	t.Helper()
+	var row messageRow
+	err := database.DB.QueryRow(context.Background(), `
+		SELECT status, status_reason, message_id, sent_at, send_after, claimed_at FROM messages WHERE id = $1
+	`, id).Scan(&row.Status, &row.StatusReason, &row.MessageID, &row.SentAt, &row.SendAfter, &row.ClaimedAt)
+	if err != nil {
+		t.Fatalf("failed to read message %d: %v", id, err)
+	}
+	return row
+}
+
+func getAttemptError(t *testing.T, attemptID int) (finished bool, errMsg *string, providerID *uuid.UUID) {
+	t.Helper()
+	var finishedAt *time.Time
+	err := database.DB.QueryRow(context.Background(), `
+		SELECT finished_at, error, provider_message_id FROM message_attempts WHERE id = $1
+	`, attemptID).Scan(&finishedAt, &errMsg, &providerID)
+	if err != nil {
+		t.Fatalf("failed to read attempt %d: %v", attemptID, err)
+	}
+	return finishedAt != nil, errMsg, providerID
+}
+
+// expireClaim moves the claim of message id an hour into the past.
+func expireClaim(t *testing.T, id int) {
+	t.Helper()
+	_, err := database.DB.Exec(context.Background(), `
+		UPDATE messages SET claimed_at = claimed_at - INTERVAL '1 hour' WHERE id = $1
+	`, id)
+	if err != nil {
+		t.Fatalf("failed to expire claim of message %d: %v", id, err)
+	}
+}
+
+func claimAll(t *testing.T, r *OutboxRepository, opts ClaimOptions) map[int]Claim {
+	t.Helper()
+	if opts.Limit == 0 {
+		opts.Limit = 10
+	}
+	claims, err := r.ClaimMessages(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("ClaimMessages returned error: %v", err)
+	}
+	byMessage := make(map[int]Claim, len(claims))
+	for _, claim := range claims {
+		byMessage[claim.Message.ID] = claim
+	}
+	return byMessage
+}
+
+func sentTestAttempt(claim Claim) *model.MessageAttempt {
+	now := time.Now().UTC()
+	statusCode := 202
+	var latency int64 = 5
+	providerID := uuid.New()
+	return &model.MessageAttempt{
+		ID:                claim.AttemptID,
+		MessageID:         claim.Message.ID,
+		StartedAt:         now,
+		FinishedAt:        &now,
+		StatusCode:        &statusCode,
+		LatencyMs:         &latency,
+		ProviderMessageID: &providerID,
+	}
+}
+
+func failedTestAttempt(claim Claim) *model.MessageAttempt {
+	now := time.Now().UTC()
+	errMsg := "unexpected status code: 500"
+	return &model.MessageAttempt{ID: claim.AttemptID, MessageID: claim.Message.ID, StartedAt: now, FinishedAt: &now, Error: &errMsg}
+}
+
+// expiredBefore is a claim cutoff that every claim moved back by expireClaim
+// is older than, and no fresh claim is.
+func expiredBefore() time.Time {
+	return time.Now().UTC().Add(-30 * time.Minute)
+}
+
+func TestOutboxCompleteAttempt(t *testing.T) {
+	useTestDB(t)
+	r := NewOutboxRepository()
+	ctx := context.Background()
+	id := insertTestMessage(t, nil)
+
+	claim, ok := claimAll(t, r, ClaimOptions{})[id]
+	if !ok {
+		t.Fatal("ClaimMessages did not claim the unsent message")
+	}
+	if claim.Message.Status != model.StatusProcessing || claim.AttemptID == 0 {
+		t.Fatalf("claim = %+v, want a processing message with an open attempt", claim)
+	}
+	if row := getMessageRow(t, id); row.Status != model.StatusProcessing || row.ClaimedAt == nil {
+		t.Fatalf("claimed message = %+v, want processing with claimed_at", row)
+	}
+	if again := claimAll(t, r, ClaimOptions{}); len(again) != 0 {
+		t.Fatalf("ClaimMessages claimed %d processing messages again", len(again))
+	}
+
+	attempt := sentTestAttempt(claim)
+	if err := r.CompleteAttempt(ctx, claim, attempt); err != nil {
+		t.Fatalf("CompleteAttempt returned error: %v", err)
+	}
+	row := getMessageRow(t, id)
+	if row.Status != model.StatusSent || row.MessageID == nil || *row.MessageID != *attempt.ProviderMessageID {
+		t.Errorf("completed message = %+v, want sent with the provider id", row)
+	}
+	if row.SentAt == nil || row.ClaimedAt != nil {
+		t.Errorf("completed message = %+v, want sent_at set and claimed_at cleared", row)
+	}
+	if finished, _, providerID := getAttemptError(t, claim.AttemptID); !finished || providerID == nil {
+		t.Error("completed attempt was not closed with the provider id")
+	}
+
+	if err := r.CompleteAttempt(ctx, claim, attempt); err == nil {
+		t.Error("CompleteAttempt of a message that is no longer claimed returned no error")
+	}
+}
+
+func TestOutboxFailAttempt(t *testing.T) {
+	tests := []struct {
+		name       string
+		cancelled  bool
+		next       model.MessageStatus
+		wantStatus model.MessageStatus
+		wantReason string
+		reclaimed  bool
+	}{
+		{name: "retry", next: model.StatusUnsent, wantStatus: model.StatusUnsent, reclaimed: true},
+		{name: "give up", next: model.StatusFailed, wantStatus: model.StatusFailed},
+		{name: "retry in cancelled campaign", cancelled: true, next: model.StatusUnsent, wantStatus: model.StatusCancelled, wantReason: campaignCancelledReason},
+		{name: "give up in cancelled campaign", cancelled: true, next: model.StatusFailed, wantStatus: model.StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestDB(t)
+			r := NewOutboxRepository()
+
+			var campaignID *int
+			if tt.cancelled {
+				id := insertTestCampaign(t)
+				campaignID = &id
+			}
+			id := insertTestMessage(t, campaignID)
+			claim := claimAll(t, r, ClaimOptions{})[id]
+			if tt.cancelled {
+				cancelTestCampaign(t, *campaignID)
+			}
+
+			if err := r.FailAttempt(context.Background(), claim, failedTestAttempt(claim), tt.next); err != nil {
+				t.Fatalf("FailAttempt returned error: %v", err)
+			}
+
+			row := getMessageRow(t, id)
+			if row.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", row.Status, tt.wantStatus)
+			}
+			var reason string
+			if row.StatusReason != nil {
+				reason = *row.StatusReason
+			}
+			if reason != tt.wantReason {
+				t.Errorf("status_reason = %q, want %q", reason, tt.wantReason)
+			}
+			if finished, errMsg, _ := getAttemptError(t, claim.AttemptID); !finished || errMsg == nil {
+				t.Error("failed attempt was not closed with its error")
+			}
+
+			_, reclaimed := claimAll(t, r, ClaimOptions{})[id]
+			if reclaimed != tt.reclaimed {
+				t.Errorf("claimed again = %v, want %v", reclaimed, tt.reclaimed)
+			}
+		})
+	}
+}
+
+func TestOutboxDeferMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		cancelled  bool
+		wantStatus model.MessageStatus
+	}{
+		{name: "unsent until later", wantStatus: model.StatusUnsent},
+		{name: "cancelled campaign", cancelled: true, wantStatus: model.StatusCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestDB(t)
+			r := NewOutboxRepository()
+			ctx := context.Background()
+
+			var campaignID *int
+			if tt.cancelled {
+				id := insertTestCampaign(t)
+				campaignID = &id
+			}
+			id := insertTestMessage(t, campaignID)
+			claim := claimAll(t, r, ClaimOptions{})[id]
+			if tt.cancelled {
+				cancelTestCampaign(t, *campaignID)
+			}
+
+			until := time.Now().UTC().Add(time.Hour)
+			if err := r.DeferMessage(ctx, claim, until); err != nil {
+				t.Fatalf("DeferMessage returned error: %v", err)
+			}
+
+			row := getMessageRow(t, id)
+			if row.Status != tt.wantStatus || row.SendAfter == nil || row.ClaimedAt != nil {
+				t.Errorf("deferred message = %+v, want %s with send_after and no claim", row, tt.wantStatus)
+			}
+			var attempts int
+			if err := database.DB.QueryRow(ctx, `SELECT COUNT(*) FROM message_attempts WHERE message_id = $1`, id).Scan(&attempts); err != nil {
+				t.Fatalf("failed to count attempts: %v", err)
+			}
+			if attempts != 0 {
+				t.Errorf("deferred message has %d attempts, want the claim's attempt discarded", attempts)
+			}
+			if _, claimed := claimAll(t, r, ClaimOptions{})[id]; claimed {
+				t.Error("deferred message was claimed before send_after")
+			}
+		})
+	}
+}
+
+func TestOutboxReclaimExpiredClaims(t *testing.T) {
+	useTestDB(t)
+	r := NewOutboxRepository()
+	ctx := context.Background()
+
+	abandoned := insertTestMessage(t, nil)
+	recorded := insertTestMessage(t, nil)
+	claims := claimAll(t, r, ClaimOptions{})
+	if err := r.RecordSentAttempt(ctx, claims[recorded], sentTestAttempt(claims[recorded])); err != nil {
+		t.Fatalf("RecordSentAttempt returned error: %v", err)
+	}
+	expireClaim(t, abandoned)
+	expireClaim(t, recorded)
+
+	before := expiredBefore()
+	if fresh := claimAll(t, r, ClaimOptions{ReclaimBefore: &before}); len(fresh) != 1 {
+		t.Fatalf("ClaimMessages claimed %d messages, want only the abandoned one", len(fresh))
+	} else if reclaim, ok := fresh[abandoned]; !ok || reclaim.AttemptID == claims[abandoned].AttemptID {
+		t.Fatalf("abandoned message was not claimed again with a new attempt")
+	}
+
+	if _, errMsg, _ := getAttemptError(t, claims[abandoned].AttemptID); errMsg == nil || *errMsg != claimExpiredError {
+		t.Errorf("abandoned attempt error = %v, want %q", errMsg, claimExpiredError)
+	}
+
+	row := getMessageRow(t, recorded)
+	_, _, providerID := getAttemptError(t, claims[recorded].AttemptID)
+	if row.Status != model.StatusSent || row.MessageID == nil || providerID == nil || *row.MessageID != *providerID {
+		t.Errorf("message with a recorded send = %+v, want sent with the recorded provider id", row)
+	}
+}
+
+func TestOutboxFailExpiredClaims(t *testing.T) {
+	useTestDB(t)
+	r := NewOutboxRepository()
+	ctx := context.Background()
+
+	abandoned := insertTestMessage(t, nil)
+	recorded := insertTestMessage(t, nil)
+	campaignID := insertTestCampaign(t)
+	cancelled := insertTestMessage(t, &campaignID)
+	fresh := insertTestMessage(t, nil)
+	claims := claimAll(t, r, ClaimOptions{})
+	if len(claims) != 4 {
+		t.Fatalf("ClaimMessages claimed %d messages, want 4", len(claims))
+	}
+	cancelTestCampaign(t, campaignID)
+	sent := sentTestAttempt(claims[recorded])
+	if err := r.RecordSentAttempt(ctx, claims[recorded], sent); err != nil {
+		t.Fatalf("RecordSentAttempt returned error: %v", err)
+	}
+	for _, id := range []int{abandoned, recorded, cancelled} {
+		expireClaim(t, id)
+	}
+
+	failed, err := r.FailExpiredClaims(ctx, expiredBefore())
+	if err != nil {
+		t.Fatalf("FailExpiredClaims returned error: %v", err)
+	}
+	if failed != 1 {
+		t.Errorf("FailExpiredClaims failed %d messages, want 1", failed)
+	}
+
+	tests := []struct {
+		name string
+		id   int
+		want model.MessageStatus
+	}{
+		{name: "abandoned", id: abandoned, want: model.StatusFailed},
+		{name: "recorded send", id: recorded, want: model.StatusSent},
+		{name: "cancelled campaign", id: cancelled, want: model.StatusCancelled},
+		{name: "fresh claim", id: fresh, want: model.StatusProcessing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if row := getMessageRow(t, tt.id); row.Status != tt.want {
+				t.Errorf("status = %s, want %s", row.Status, tt.want)
+			}
+		})
+	}
+
+	if row := getMessageRow(t, recorded); row.MessageID == nil || *row.MessageID != *sent.ProviderMessageID {
+		t.Errorf("settled message_id = %v, want the recorded %v", row.MessageID, *sent.ProviderMessageID)
+	}
+	if _, errMsg, _ := getAttemptError(t, claims[abandoned].AttemptID); errMsg == nil || *errMsg != claimExpiredError {
+		t.Errorf("abandoned attempt error = %v, want %q", errMsg, claimExpiredError)
+	}
+}
+
+func TestUnsentOrder(t *testing.T) {
+	tests := []struct {
+		aging time.Duration
+		want  string
+	}{
+		{aging: 0, want: `priority DESC, created_at ASC`},
+		{aging: 500 * time.Millisecond, want: `priority DESC, created_at ASC`},
+		{aging: 5 * time.Minute, want: `priority + FLOOR(EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - created_at)) / 300) DESC, created_at ASC`},
+	}
+
+	for _, tt := range tests {
+		if got := unsentOrder(tt.aging); got != tt.want {
+			t.Errorf("unsentOrder(%v) = %q, want %q", tt.aging, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+// archivedMessageColumns, archivedAttemptColumns and archivedPartColumns are
+// copied from messages, message_attempts and message_parts to their archives.
+const archivedMessageColumns = `id, tenant_id, "to", channel, content, content_key_id, status, status_reason, priority, template_id, variables, variables_key_id, locale, timezone, critical, send_after, dedup_key, campaign_id, created_by, sent_at, message_id, delivered_at, read_at, claimed_at, created_at, updated_at`
+
+const archivedAttemptColumns = `id, message_id, started_at, finished_at, status_code, latency_ms, response_body, provider_message_id, error, created_at`
+
+const archivedPartColumns = `message_id, number, total, content, content_key_id, status, provider_message_id, error, sent_at, created_at, updated_at`
+
+const retentionRunColumns = `id, mode, purged, total, COALESCE(error, ''), started_at, finished_at`
+
+// RetentionRepository purges old messages and expired deduplication keys,
+// and records each retention run. The audit log is append-only and never
+// purged.
+type RetentionRepository struct{}
+
+func NewRetentionRepository() *RetentionRepository {
+	return &RetentionRepository{}
+}
+
+// PurgeMessages archives or deletes up to limit messages in status created
+// more than age ago, and returns how many it purged. Each call is its own
+// short transaction, and rows locked by the scheduler or a receipt are skipped
+// rather than waited for, so purging does not hold up the hot path. Attempts
+// and parts of the messages are archived or deleted with them.
+func (r *RetentionRepository) PurgeMessages(
+	ctx context.Context,
+	mode model.RetentionMode,
+	status model.MessageStatus,
+	age time.Duration,
+	limit int,
+) (int, error) {
+	batch := `
+		WITH batch AS (
+			SELECT id FROM messages
+			WHERE status = $1 AND created_at < CURRENT_TIMESTAMP - $2::double precision * INTERVAL '1 second'
+			ORDER BY created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)`
+
+	var query string
+	switch mode {
+	case model.RetentionArchive:
+		// Every statement in the WITH runs, whether or not the final INSERT
+		// reads it, so attempts and parts move in the same statement as their
+		// messages, before deleting the messages could cascade to them.
+		query = batch + `, moved_attempts AS (
+			DELETE FROM message_attempts WHERE message_id IN (SELECT id FROM batch)
+			RETURNING ` + archivedAttemptColumns + `
+		), archived_attempts AS (
+			INSERT INTO message_attempts_archive (` + archivedAttemptColumns + `)
+			SELECT ` + archivedAttemptColumns + ` FROM moved_attempts
+		), moved_parts AS (
+			DELETE FROM message_parts WHERE message_id IN (SELECT id FROM batch)
+			RETURNING ` + archivedPartColumns + `
+		), archived_parts AS (
+			INSERT INTO message_parts_archive (` + archivedPartColumns + `)
+			SELECT ` + archivedPartColumns + ` FROM moved_parts
+		), moved AS (
+			DELETE FROM messages WHERE id IN (SELECT id FROM batch)
+			RETURNING ` + archivedMessageColumns + `
+		)
+		INSERT INTO messages_archive (` + archivedMessageColumns + `)
+		SELECT ` + archivedMessageColumns + ` FROM moved
+		`
+	case model.RetentionDelete:
+		query = batch + `
+		DELETE FROM messages WHERE id IN (SELECT id FROM batch)
+		`
+	default:
+		return 0, fmt.Errorf("unknown retention mode %q", mode)
+	}
+
+	tag, err := database.DB.Exec(ctx, query, status, age.Seconds(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge %s messages: %w", status, err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// PruneDedupKeys deletes up to limit expired deduplication keys and returns
+// how many it deleted. Keys being claimed by a new message are skipped rather
+// than waited for.
+func (r *RetentionRepository) PruneDedupKeys(ctx context.Context, limit int) (int, error) {
+	tag, err := database.DB.Exec(ctx, `
+		DELETE FROM message_dedup_keys
+		WHERE (tenant_id, recipient, dedup_key) IN (
+			SELECT tenant_id, recipient, dedup_key FROM message_dedup_keys
+			WHERE expires_at <= CURRENT_TIMESTAMP
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune dedup keys: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// RecordRetentionRun stores the report of a retention run.
+func (r *RetentionRepository) RecordRetentionRun(ctx context.Context, run *model.RetentionRun) error {
+	err := database.DB.QueryRow(ctx, `
+		INSERT INTO retention_runs (mode, purged, total, error, started_at, finished_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING id
+	`, run.Mode, run.Purged, run.Total, run.Error, run.StartedAt, run.FinishedAt).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record retention run: %w", err)
+	}
+
+	return nil
+}
+
+// ListRetentionRuns returns recorded retention runs, newest first.
+func (r *RetentionRepository) ListRetentionRuns(ctx context.Context, limit, offset int) ([]model.RetentionRun, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT `+retentionRunColumns+`
+		FROM retention_runs
+		ORDER BY id DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []model.RetentionRun{}
+	for rows.Next() {
+		var run model.RetentionRun
+		err := rows.Scan(
+			&run.ID,
+			&run.Mode,
+			&run.Purged,
+			&run.Total,
+			&run.Error,
+			&run.StartedAt,
+			&run.FinishedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan retention run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating retention runs: %w", err)
+	}
+
+	return runs, nil
+}
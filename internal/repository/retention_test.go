@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubilayrn/ChronoGo/internal/database"
+)
+
+func insertTestDedupKey(t *testing.T, recipient, expiresIn string) {
+	t.Helper()
+	_, err := database.DB.Exec(context.Background(), `
+		INSERT INTO message_dedup_keys (recipient, dedup_key, expires_at)
+		VALUES ($1, 'order-42', CURRENT_TIMESTAMP + $2::interval)
+	`, recipient, expiresIn)
+	if err != nil {
+		t.Fatalf("failed to insert dedup key: %v", err)
+	}
+}
+
+func TestPruneDedupKeys(t *testing.T) {
+	useTestDB(t)
+	ctx := context.Background()
+	repo := NewRetentionRepository()
+
+	insertTestDedupKey(t, "+905550000001", "-1 hour")
+	insertTestDedupKey(t, "+905550000002", "-1 minute")
+	insertTestDedupKey(t, "+905550000003", "-1 second")
+	insertTestDedupKey(t, "+905550000004", "1 hour")
+
+	pruned, err := repo.PruneDedupKeys(ctx, 2)
+	if err != nil {
+		t.Fatalf("PruneDedupKeys returned error: %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("first batch pruned %d keys, want 2", pruned)
+	}
+
+	pruned, err = repo.PruneDedupKeys(ctx, 2)
+	if err != nil {
+		t.Fatalf("PruneDedupKeys returned error: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("second batch pruned %d keys, want 1", pruned)
+	}
+
+	var recipients []string
+	rows, err := database.DB.Query(ctx, `SELECT recipient FROM message_dedup_keys`)
+	if err != nil {
+		t.Fatalf("failed to read dedup keys: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var recipient string
+		if err := rows.Scan(&recipient); err != nil {
+			t.Fatalf("failed to scan dedup key: %v", err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	if len(recipients) != 1 || recipients[0] != "+905550000004" {
+		t.Errorf("remaining keys = %v, want only the unexpired one", recipients)
+	}
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+var ErrSuppressionNotFound = errors.New("suppression not found")
+
+const suppressionColumns = `tenant_id, recipient, reason, created_at`
+
+type SuppressionRepository struct{}
+
+func NewSuppressionRepository() *SuppressionRepository {
+	return &SuppressionRepository{}
+}
+
+// AddSuppression adds the recipient to the tenant's suppressions or updates
+// the reason if it is already suppressed.
+func (r *SuppressionRepository) AddSuppression(ctx context.Context, suppression *model.Suppression) error {
+	if err := upsertSuppression(ctx, database.DB, suppression); err != nil {
+		return fmt.Errorf("failed to add suppression: %w", err)
+	}
+	return nil
+}
+
+// ImportSuppressions adds all suppressions in one transaction.
+func (r *SuppressionRepository) ImportSuppressions(ctx context.Context, suppressions []*model.Suppression) error {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, suppression := range suppressions {
+		if err := upsertSuppression(ctx, tx, suppression); err != nil {
+			return fmt.Errorf("failed to import suppression: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit suppressions: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SuppressionRepository) GetSuppression(ctx context.Context, tenantID, recipient string) (*model.Suppression, error) {
+	query := `
+		SELECT ` + suppressionColumns + `
+		FROM suppressions
+		WHERE tenant_id = $1 AND recipient = $2
+	`
+
+	var suppression model.Suppression
+	err := scanSuppression(database.DB.QueryRow(ctx, query, tenantID, recipient), &suppression)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSuppressionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suppression: %w", err)
+	}
+
+	return &suppression, nil
+}
+
+// FindSuppressions returns the tenant's suppressions among recipients, keyed
+// by recipient.
+func (r *SuppressionRepository) FindSuppressions(ctx context.Context, tenantID string, recipients []string) (map[string]model.Suppression, error) {
+	query := `
+		SELECT ` + suppressionColumns + `
+		FROM suppressions
+		WHERE tenant_id = $1 AND recipient = ANY($2)
+	`
+
+	rows, err := database.DB.Query(ctx, query, tenantID, recipients)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suppressions: %w", err)
+	}
+
+	suppressions, err := collectSuppressions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]model.Suppression, len(suppressions))
+	for _, suppression := range suppressions {
+		found[suppression.Recipient] = suppression
+	}
+	return found, nil
+}
+
+func (r *SuppressionRepository) ListSuppressions(ctx context.Context, tenantID string, limit, offset int) ([]model.Suppression, error) {
+	query := `
+		SELECT ` + suppressionColumns + `
+		FROM suppressions
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC, recipient ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := database.DB.Query(ctx, query, tenantID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suppressions: %w", err)
+	}
+
+	return collectSuppressions(rows)
+}
+
+func (r *SuppressionRepository) DeleteSuppression(ctx context.Context, tenantID, recipient string) error {
+	tag, err := database.DB.Exec(ctx,
+		`DELETE FROM suppressions WHERE tenant_id = $1 AND recipient = $2`,
+		tenantID, recipient,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete suppression: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSuppressionNotFound
+	}
+
+	return nil
+}
+
+func upsertSuppression(ctx context.Context, q querier, suppression *model.Suppression) error {
+	query := `
+		INSERT INTO suppressions (tenant_id, recipient, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_id, recipient) DO UPDATE SET reason = EXCLUDED.reason
+		RETURNING ` + suppressionColumns + `
+	`
+
+	return scanSuppression(q.QueryRow(ctx, query, suppression.TenantID, suppression.Recipient, suppression.Reason), suppression)
+}
+
+func scanSuppression(row pgx.Row, suppression *model.Suppression) error {
+	return row.Scan(
+		&suppression.TenantID,
+		&suppression.Recipient,
+		&suppression.Reason,
+		&suppression.CreatedAt,
+	)
+}
+
+func collectSuppressions(rows pgx.Rows) ([]model.Suppression, error) {
+	defer rows.Close()
+
+	suppressions := []model.Suppression{}
+	for rows.Next() {
+		var suppression model.Suppression
+		if err := scanSuppression(rows, &suppression); err != nil {
+			return nil, fmt.Errorf("failed to scan suppression: %w", err)
+		}
+		suppressions = append(suppressions, suppression)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating suppressions: %w", err)
+	}
+
+	return suppressions, nil
+}
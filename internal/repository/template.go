@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+var (
+	ErrTemplateNotFound  = errors.New("template not found")
+	ErrTemplateNameTaken = errors.New("template name already exists")
+	ErrTemplateInUse     = errors.New("template is referenced by messages")
+	ErrVariantNotFound   = errors.New("template variant not found")
+)
+
+const (
+	templateColumns = `id, tenant_id, name, body, created_at, updated_at`
+	variantColumns  = `id, template_id, locale, body, created_at, updated_at`
+)
+
+// variantTenantCondition restricts variants to those of templates of the
+// tenant in $2.
+const variantTenantCondition = `template_id IN (SELECT id FROM templates WHERE tenant_id = $2)`
+
+type TemplateRepository struct{}
+
+func NewTemplateRepository() *TemplateRepository {
+	return &TemplateRepository{}
+}
+
+func (r *TemplateRepository) CreateTemplate(ctx context.Context, tmpl *model.Template) error {
+	query := `
+		INSERT INTO templates (tenant_id, name, body)
+		VALUES ($1, $2, $3)
+		RETURNING ` + templateColumns + `
+	`
+
+	err := scanTemplate(database.DB.QueryRow(ctx, query, tmpl.TenantID, tmpl.Name, tmpl.Body), tmpl)
+	if isUniqueViolation(err) {
+		return ErrTemplateNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TemplateRepository) GetTemplate(ctx context.Context, tenantID string, id int) (*model.Template, error) {
+	query := `
+		SELECT ` + templateColumns + `
+		FROM templates
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	var tmpl model.Template
+	err := scanTemplate(database.DB.QueryRow(ctx, query, id, tenantID), &tmpl)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	return &tmpl, nil
+}
+
+func (r *TemplateRepository) ListTemplates(ctx context.Context, tenantID string) ([]model.Template, error) {
+	query := `
+		SELECT ` + templateColumns + `
+		FROM templates
+		WHERE tenant_id = $1
+		ORDER BY name ASC
+	`
+
+	rows, err := database.DB.Query(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []model.Template{}
+	for rows.Next() {
+		var tmpl model.Template
+		if err := scanTemplate(rows, &tmpl); err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, tmpl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+func (r *TemplateRepository) UpdateTemplate(ctx context.Context, tmpl *model.Template) error {
+	query := `
+		UPDATE templates
+		SET name = $1, body = $2
+		WHERE id = $3 AND tenant_id = $4
+		RETURNING ` + templateColumns + `
+	`
+
+	err := scanTemplate(database.DB.QueryRow(ctx, query, tmpl.Name, tmpl.Body, tmpl.ID, tmpl.TenantID), tmpl)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTemplateNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrTemplateNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update template: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TemplateRepository) DeleteTemplate(ctx context.Context, tenantID string, id int) error {
+	tag, err := database.DB.Exec(ctx, `DELETE FROM templates WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if isForeignKeyViolation(err) {
+		return ErrTemplateInUse
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTemplateNotFound
+	}
+
+	return nil
+}
+
+func scanTemplate(row pgx.Row, tmpl *model.Template) error {
+	return row.Scan(
+		&tmpl.ID,
+		&tmpl.TenantID,
+		&tmpl.Name,
+		&tmpl.Body,
+		&tmpl.CreatedAt,
+		&tmpl.UpdatedAt,
+	)
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// ResolveBody returns the body of the first variant of the tenant's template
+// matching locales, in order, or the template's own body when none does.
+func (r *TemplateRepository) ResolveBody(ctx context.Context, tenantID string, templateID int, locales []string) (string, error) {
+	query := `
+		SELECT COALESCE(
+			(
+				SELECT v.body
+				FROM template_variants v
+				WHERE v.template_id = t.id AND v.locale = ANY($2)
+				ORDER BY array_position($2, v.locale::text)
+				LIMIT 1
+			),
+			t.body
+		)
+		FROM templates t
+		WHERE t.id = $1 AND t.tenant_id = $3
+	`
+
+	var body string
+	err := database.DB.QueryRow(ctx, query, templateID, locales, tenantID).Scan(&body)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrTemplateNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve template body: %w", err)
+	}
+
+	return body, nil
+}
+
+// UpsertVariant saves the variant of the tenant's template, replacing the
+// body of an existing variant for the same locale.
+func (r *TemplateRepository) UpsertVariant(ctx context.Context, tenantID string, variant *model.TemplateVariant) error {
+	// Selecting the template inserts nothing for a template of another
+	// tenant, which is reported as not found like a missing one.
+	query := `
+		INSERT INTO template_variants (template_id, locale, body)
+		SELECT id, $2, $3 FROM templates WHERE id = $1 AND tenant_id = $4
+		ON CONFLICT (template_id, locale) DO UPDATE SET body = EXCLUDED.body
+		RETURNING ` + variantColumns + `
+	`
+
+	err := scanVariant(database.DB.QueryRow(ctx, query, variant.TemplateID, variant.Locale, variant.Body, tenantID), variant)
+	if errors.Is(err, pgx.ErrNoRows) || isForeignKeyViolation(err) {
+		return ErrTemplateNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save template variant: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TemplateRepository) ListVariants(ctx context.Context, tenantID string, templateID int) ([]model.TemplateVariant, error) {
+	query := `
+		SELECT ` + variantColumns + `
+		FROM template_variants
+		WHERE template_id = $1 AND ` + variantTenantCondition + `
+		ORDER BY locale ASC
+	`
+
+	rows, err := database.DB.Query(ctx, query, templateID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query template variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants := []model.TemplateVariant{}
+	for rows.Next() {
+		var variant model.TemplateVariant
+		if err := scanVariant(rows, &variant); err != nil {
+			return nil, fmt.Errorf("failed to scan template variant: %w", err)
+		}
+		variants = append(variants, variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating template variants: %w", err)
+	}
+
+	return variants, nil
+}
+
+func (r *TemplateRepository) GetVariant(ctx context.Context, tenantID string, templateID int, locale string) (*model.TemplateVariant, error) {
+	query := `
+		SELECT ` + variantColumns + `
+		FROM template_variants
+		WHERE template_id = $1 AND ` + variantTenantCondition + ` AND locale = $3
+	`
+
+	var variant model.TemplateVariant
+	err := scanVariant(database.DB.QueryRow(ctx, query, templateID, tenantID, locale), &variant)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template variant: %w", err)
+	}
+
+	return &variant, nil
+}
+
+func (r *TemplateRepository) DeleteVariant(ctx context.Context, tenantID string, templateID int, locale string) error {
+	tag, err := database.DB.Exec(ctx,
+		`DELETE FROM template_variants WHERE template_id = $1 AND `+variantTenantCondition+` AND locale = $3`,
+		templateID, tenantID, locale,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete template variant: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrVariantNotFound
+	}
+
+	return nil
+}
+
+func scanVariant(row pgx.Row, variant *model.TemplateVariant) error {
+	return row.Scan(
+		&variant.ID,
+		&variant.TemplateID,
+		&variant.Locale,
+		&variant.Body,
+		&variant.CreatedAt,
+		&variant.UpdatedAt,
+	)
+}
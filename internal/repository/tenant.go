@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kubilayrn/ChronoGo/internal/database"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+)
+
+var ErrTenantNotFound = errors.New("tenant not found")
+
+const tenantColumns = `id, name, COALESCE(webhook_url, ''), COALESCE(webhook_auth_key, ''), batch_limit, rate_limit, rate_limit_per_key, created_at, updated_at`
+
+type TenantRepository struct{}
+
+func NewTenantRepository() *TenantRepository {
+	return &TenantRepository{}
+}
+
+// UpsertTenant creates the tenant or replaces its settings. An empty
+// WebhookAuthKey keeps the stored key, so clients never have to read it back;
+// clearing WebhookURL clears both.
+func (r *TenantRepository) UpsertTenant(ctx context.Context, tenant *model.Tenant) error {
+	query := `
+		INSERT INTO tenants (id, name, webhook_url, webhook_auth_key, batch_limit, rate_limit, rate_limit_per_key)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE
+		SET name = EXCLUDED.name,
+			webhook_url = EXCLUDED.webhook_url,
+			webhook_auth_key = CASE
+				WHEN EXCLUDED.webhook_url IS NULL THEN NULL
+				ELSE COALESCE(EXCLUDED.webhook_auth_key, tenants.webhook_auth_key) END,
+			batch_limit = EXCLUDED.batch_limit,
+			rate_limit = EXCLUDED.rate_limit,
+			rate_limit_per_key = EXCLUDED.rate_limit_per_key
+		RETURNING ` + tenantColumns + `
+	`
+
+	err := scanTenant(database.DB.QueryRow(ctx, query,
+		tenant.ID, tenant.Name, tenant.WebhookURL, tenant.WebhookAuthKey, tenant.BatchLimit, tenant.RateLimit, tenant.RateLimitPerKey,
+	), tenant)
+	if err != nil {
+		return fmt.Errorf("failed to upsert tenant: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TenantRepository) GetTenant(ctx context.Context, id string) (*model.Tenant, error) {
+	query := `
+		SELECT ` + tenantColumns + `
+		FROM tenants
+		WHERE id = $1
+	`
+
+	var tenant model.Tenant
+	err := scanTenant(database.DB.QueryRow(ctx, query, id), &tenant)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return &tenant, nil
+}
+
+// ListTenants returns every tenant ordered by id.
+func (r *TenantRepository) ListTenants(ctx context.Context) ([]model.Tenant, error) {
+	query := `
+		SELECT ` + tenantColumns + `
+		FROM tenants
+		ORDER BY id
+	`
+
+	rows, err := database.DB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := []model.Tenant{}
+	for rows.Next() {
+		var tenant model.Tenant
+		if err := scanTenant(rows, &tenant); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenants: %w", err)
+	}
+
+	return tenants, nil
+}
+
+func scanTenant(row pgx.Row, tenant *model.Tenant) error {
+	return row.Scan(
+		&tenant.ID,
+		&tenant.Name,
+		&tenant.WebhookURL,
+		&tenant.WebhookAuthKey,
+		&tenant.BatchLimit,
+		&tenant.RateLimit,
+		&tenant.RateLimitPerKey,
+		&tenant.CreatedAt,
+		&tenant.UpdatedAt,
+	)
+}
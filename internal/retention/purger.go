@@ -0,0 +1,339 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/kubilayrn/ChronoGo/internal/metrics"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var tracer = otel.Tracer("github.com/kubilayrn/ChronoGo/internal/retention")
+
+const (
+	defaultIntervalMinutes = 60
+	defaultBatchSize       = 1000
+	// batchPause spaces out batches, so purging a large backlog leaves room
+	// for the scheduler's own queries.
+	batchPause = 100 * time.Millisecond
+)
+
+// day is a retention period unit.
+const day = 24 * time.Hour
+
+// finalStatuses are the statuses retention applies to, in the order they are
+// purged. Messages still waiting to be sent are never purged.
+var finalStatuses = []model.MessageStatus{
+	model.StatusSent,
+	model.StatusDelivered,
+	model.StatusRead,
+	model.StatusUndelivered,
+	model.StatusFailed,
+	model.StatusSuppressed,
+	model.StatusDuplicate,
+	model.StatusCancelled,
+}
+
+// Purger periodically archives or deletes messages that have been in a final
+// status for longer than its retention period, and deletes expired
+// deduplication keys.
+type Purger struct {
+	mu        sync.Mutex
+	repo      *repository.RetentionRepository
+	mode      model.RetentionMode
+	periods   map[model.MessageStatus]time.Duration
+	interval  time.Duration
+	batchSize int
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewPurgerFromEnv reads RETENTION_DAYS, the period for every final status,
+// RETENTION_DAYS_BY_STATUS, comma separated status=days overrides,
+// RETENTION_MODE, RETENTION_INTERVAL_MINUTES and RETENTION_BATCH_SIZE. A
+// period of 0, the default, keeps messages of that status forever.
+//
+// Unlike most settings, an invalid period or mode is an error rather than a
+// warning, as guessing could delete messages that were meant to be kept.
+func NewPurgerFromEnv(repo *repository.RetentionRepository) (*Purger, error) {
+	_ = godotenv.Load()
+
+	days := 0
+	if value := os.Getenv("RETENTION_DAYS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid RETENTION_DAYS %q", value)
+		}
+		days = parsed
+	}
+
+	periods := make(map[model.MessageStatus]time.Duration)
+	for _, status := range finalStatuses {
+		if days > 0 {
+			periods[status] = time.Duration(days) * day
+		}
+	}
+	if err := parsePeriods(os.Getenv("RETENTION_DAYS_BY_STATUS"), periods); err != nil {
+		return nil, err
+	}
+
+	mode := model.RetentionMode(os.Getenv("RETENTION_MODE"))
+	switch mode {
+	case model.RetentionArchive, model.RetentionDelete:
+	case "":
+		mode = model.RetentionArchive
+	default:
+		return nil, fmt.Errorf("invalid RETENTION_MODE %q, expected archive or delete", mode)
+	}
+
+	intervalMinutes := getEnvAsInt("RETENTION_INTERVAL_MINUTES", defaultIntervalMinutes)
+	if intervalMinutes <= 0 {
+		slog.Warn("Invalid value for RETENTION_INTERVAL_MINUTES, using default", "default", defaultIntervalMinutes)
+		intervalMinutes = defaultIntervalMinutes
+	}
+	batchSize := getEnvAsInt("RETENTION_BATCH_SIZE", defaultBatchSize)
+	if batchSize <= 0 {
+		slog.Warn("Invalid value for RETENTION_BATCH_SIZE, using default", "default", defaultBatchSize)
+		batchSize = defaultBatchSize
+	}
+
+	return &Purger{
+		repo:      repo,
+		mode:      mode,
+		periods:   periods,
+		interval:  time.Duration(intervalMinutes) * time.Minute,
+		batchSize: batchSize,
+	}, nil
+}
+
+// parsePeriods applies status=days entries to periods. 0 days keeps the
+// status forever.
+func parsePeriods(s string, periods map[model.MessageStatus]time.Duration) error {
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("invalid RETENTION_DAYS_BY_STATUS entry %q", entry)
+		}
+		status := model.MessageStatus(strings.TrimSpace(name))
+		if !status.IsFinal() {
+			return fmt.Errorf("invalid RETENTION_DAYS_BY_STATUS entry %q: %q is not a final status", entry, status)
+		}
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || days < 0 {
+			return fmt.Errorf("invalid RETENTION_DAYS_BY_STATUS entry %q: days must be a non-negative integer", entry)
+		}
+
+		if days == 0 {
+			delete(periods, status)
+		} else {
+			periods[status] = time.Duration(days) * day
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether any status has a retention period.
+func (p *Purger) Enabled() bool {
+	return len(p.periods) > 0
+}
+
+// Start runs the policy right away and then every interval, until Stop.
+// Expired deduplication keys are pruned even when no retention period is
+// configured.
+func (p *Purger) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	attrs := []any{"mode", p.mode, "interval", p.interval, "batch_size", p.batchSize}
+	for _, status := range finalStatuses {
+		if period, ok := p.periods[status]; ok {
+			attrs = append(attrs, slog.Int(string(status)+"_days", int(period/day)))
+		}
+	}
+	slog.Info("Retention started", attrs...)
+
+	go p.run(ctx)
+}
+
+// Stop stops the periodic runs, waiting for the batch in progress.
+func (p *Purger) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel == nil {
+		return
+	}
+
+	p.cancel()
+	<-p.done
+	p.cancel = nil
+
+	slog.Info("Retention stopped")
+}
+
+func (p *Purger) run(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if p.Enabled() {
+			p.Run(ctx)
+		}
+		p.pruneDedupKeys(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run applies the policy once: for each status with a retention period it
+// purges batches until no message past the period is left. The report is
+// logged, recorded and returned.
+func (p *Purger) Run(ctx context.Context) model.RetentionRun {
+	ctx, span := tracer.Start(ctx, "Purger.Run")
+	defer span.End()
+
+	run := model.RetentionRun{
+		Mode:      p.mode,
+		Purged:    make(map[model.MessageStatus]int),
+		StartedAt: time.Now(),
+	}
+
+	for _, status := range finalStatuses {
+		period, ok := p.periods[status]
+		if !ok {
+			continue
+		}
+
+		purged, err := p.purge(ctx, status, period)
+		if purged > 0 {
+			run.Purged[status] = purged
+			run.Total += purged
+		}
+		if err != nil {
+			run.Error = err.Error()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			break
+		}
+	}
+
+	run.FinishedAt = time.Now()
+	span.SetAttributes(attribute.Int("chronogo.retention.purged", run.Total))
+
+	if run.Error != "" {
+		slog.ErrorContext(ctx, "Retention run stopped early", "mode", run.Mode, "purged", run.Purged, "total", run.Total, "error", run.Error)
+	} else if run.Total > 0 {
+		slog.InfoContext(ctx, "Retention run finished", "mode", run.Mode, "purged", run.Purged, "total", run.Total)
+	} else {
+		slog.DebugContext(ctx, "Retention run found nothing to purge")
+	}
+
+	// Recorded even when cancelled, so the report covers what was purged.
+	if err := p.repo.RecordRetentionRun(context.WithoutCancel(ctx), &run); err != nil {
+		slog.ErrorContext(ctx, "Failed to record retention run", "error", err)
+	}
+
+	return run
+}
+
+// purge purges messages of status older than period in batches, returning
+// how many it purged before it ran out or failed.
+func (p *Purger) purge(ctx context.Context, status model.MessageStatus, period time.Duration) (int, error) {
+	total := 0
+	for {
+		purged, err := p.repo.PurgeMessages(ctx, p.mode, status, period, p.batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += purged
+		metrics.MessagesPurged(string(status), string(p.mode), purged)
+
+		// A short batch means the rest are gone or locked; locked ones are
+		// picked up by the next run.
+		if purged < p.batchSize {
+			return total, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(batchPause):
+		}
+	}
+}
+
+// pruneDedupKeys deletes expired deduplication keys in batches until none
+// are left.
+func (p *Purger) pruneDedupKeys(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "Purger.pruneDedupKeys")
+	defer span.End()
+
+	total := 0
+	for {
+		pruned, err := p.repo.PruneDedupKeys(ctx, p.batchSize)
+		total += pruned
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			slog.ErrorContext(ctx, "Pruning dedup keys stopped early", "pruned", total, "error", err)
+			return
+		}
+		if pruned < p.batchSize {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(batchPause):
+		}
+	}
+
+	span.SetAttributes(attribute.Int("chronogo.retention.dedup_keys_pruned", total))
+	if total > 0 {
+		slog.InfoContext(ctx, "Pruned expired dedup keys", "pruned", total)
+	}
+}
+
+func getEnvAsInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("Invalid value for "+key+", using default", "default", defaultValue)
+		return defaultValue
+	}
+	return intValue
+}
@@ -2,21 +2,39 @@ package sender
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
+	"github.com/kubilayrn/ChronoGo/internal/metrics"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type WebhookRequest struct {
 	To      string `json:"to"`
 	Content string `json:"content"`
+	// Channel is only sent for messages that are not on the default webhook
+	// channel, so the provider's existing contract is unchanged.
+	Channel string `json:"channel,omitempty"`
+	// Part links the parts of a message too long for one segment.
+	Part *WebhookPart `json:"part,omitempty"`
+}
+
+// WebhookPart tells the provider how to reassemble a message sent in parts.
+type WebhookPart struct {
+	// Reference is the same for all parts of a message.
+	Reference int `json:"reference"`
+	Number    int `json:"number"`
+	Total     int `json:"total"`
 }
 
 type WebhookResponse struct {
@@ -24,10 +42,28 @@ type WebhookResponse struct {
 	MessageID uuid.UUID `json:"messageId"`
 }
 
+// maxResponseBodyLength caps how much of a webhook response is kept on a SendResult.
+const maxResponseBodyLength = 1024
+
+// SendResult describes a single call to the webhook, successful or not.
+type SendResult struct {
+	MessageID    *uuid.UUID
+	StatusCode   int
+	ResponseBody string
+	StartedAt    time.Time
+	FinishedAt   time.Time
+}
+
+func (r *SendResult) Latency() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
 type WebhookSender struct {
 	client  *http.Client
 	url     string
 	authKey string
+	// tenant is set on senders posting to a tenant's own webhook.
+	tenant string
 }
 
 func NewWebhookSender() *WebhookSender {
@@ -37,33 +73,111 @@ func NewWebhookSender() *WebhookSender {
 	authKey := os.Getenv("WEBHOOK_AUTH_KEY")
 
 	if url == "" {
-		log.Fatal("WEBHOOK_URL environment variable is required")
+		slog.Error("WEBHOOK_URL environment variable is required")
+		os.Exit(1)
 	}
 	if authKey == "" {
-		log.Fatal("WEBHOOK_AUTH_KEY environment variable is required")
+		slog.Error("WEBHOOK_AUTH_KEY environment variable is required")
+		os.Exit(1)
 	}
 
 	return &WebhookSender{
 		client: &http.Client{
 			Timeout: 30 * time.Second,
+			// Traces each call and passes the trace context to the provider
+			// in the W3C traceparent header.
+			Transport: otelhttp.NewTransport(http.DefaultTransport,
+				otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+					return "webhook " + r.Method
+				}),
+			),
 		},
 		url:     url,
 		authKey: authKey,
 	}
 }
 
-func (s *WebhookSender) SendMessage(to, content string) (*uuid.UUID, error) {
+// ForTenant returns a sender posting to the tenant's webhook, or s itself for
+// tenants without one. The returned sender shares s's HTTP client.
+func (s *WebhookSender) ForTenant(tenant model.Tenant) *WebhookSender {
+	if tenant.WebhookURL == "" {
+		return s
+	}
+	return &WebhookSender{
+		client:  s.client,
+		url:     tenant.WebhookURL,
+		authKey: tenant.WebhookAuthKey,
+		tenant:  tenant.ID,
+	}
+}
+
+// Tenant returns the tenant whose own webhook s posts to, or "" for the
+// shared webhook.
+func (s *WebhookSender) Tenant() string {
+	return s.tenant
+}
+
+// SendMessage posts the message to the webhook. The returned SendResult is never
+// nil, so callers can record the attempt even when an error is returned.
+func (s *WebhookSender) SendMessage(ctx context.Context, msg model.Message) (*SendResult, error) {
+	result := &SendResult{StartedAt: time.Now()}
+	defer func() {
+		result.FinishedAt = time.Now()
+		metrics.ObserveWebhook(result.Latency())
+	}()
+
+	messageID, err := s.send(ctx, result, newWebhookRequest(msg, msg.Content))
+	if err != nil {
+		return result, err
+	}
+
+	result.MessageID = messageID
+	return result, nil
+}
+
+// SendPart posts one part of a message sent in parts, linked to the other
+// parts by the message id. Like SendMessage it never returns a nil result.
+func (s *WebhookSender) SendPart(ctx context.Context, msg model.Message, part model.MessagePart) (*SendResult, error) {
+	result := &SendResult{StartedAt: time.Now()}
+	defer func() {
+		result.FinishedAt = time.Now()
+		metrics.ObserveWebhook(result.Latency())
+	}()
+
+	payload := newWebhookRequest(msg, part.Content)
+	payload.Part = &WebhookPart{
+		Reference: msg.ID,
+		Number:    part.Number,
+		Total:     part.Total,
+	}
+
+	messageID, err := s.send(ctx, result, payload)
+	if err != nil {
+		return result, err
+	}
+
+	result.MessageID = messageID
+	return result, nil
+}
+
+func newWebhookRequest(msg model.Message, content string) WebhookRequest {
 	payload := WebhookRequest{
-		To:      to,
+		To:      msg.To,
 		Content: content,
 	}
+	if msg.Channel != "" && msg.Channel != model.DefaultChannel {
+		payload.Channel = string(msg.Channel)
+	}
+	return payload
+}
 
+func (s *WebhookSender) send(ctx context.Context, result *SendResult, payload WebhookRequest) (*uuid.UUID, error) {
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", s.url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -77,13 +191,19 @@ func (s *WebhookSender) SendMessage(to, content string) (*uuid.UUID, error) {
 	}
 	defer resp.Body.Close()
 
+	result.StatusCode = resp.StatusCode
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	result.ResponseBody = truncate(string(body), maxResponseBodyLength)
+
+	// The body is kept on the result rather than in errors, which are logged
+	// and may carry recipients or content echoed by the provider.
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-		return nil, fmt.Errorf("unexpected status code: %d, response: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	var webhookResp WebhookResponse
@@ -93,19 +213,30 @@ func (s *WebhookSender) SendMessage(to, content string) (*uuid.UUID, error) {
 			// Check if it's HTML or plain text (not JSON)
 			if len(responseStr) > 0 && (responseStr[0] != '{' && responseStr[0] != '[') {
 				mockID := uuid.New()
-				log.Printf("Warning: Webhook returned non-JSON response (HTML/text), using mock messageId: %s", mockID.String())
+				slog.WarnContext(ctx, "Webhook returned non-JSON response (HTML/text), using mock messageId", "message_id", mockID)
 				return &mockID, nil
 			}
 		}
-		return nil, fmt.Errorf("failed to unmarshal response: %w, body: %s", err, responseStr)
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	// check response messageId
 	if webhookResp.MessageID == uuid.Nil {
 		mockID := uuid.New()
-		log.Printf("Warning: Webhook returned empty messageId, using mock: %s", mockID.String())
+		slog.WarnContext(ctx, "Webhook returned empty messageId, using mock", "message_id", mockID)
 		return &mockID, nil
 	}
 
 	return &webhookResp.MessageID, nil
 }
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	// avoid cutting a multi-byte rune in half
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
@@ -0,0 +1,101 @@
+package sms
+
+import "unicode/utf16"
+
+// Encoding is the character set a message is sent in. It decides how many
+// characters fit in one segment.
+type Encoding string
+
+const (
+	GSM7 Encoding = "gsm7"
+	UCS2 Encoding = "ucs2"
+)
+
+// Segment sizes. Multipart messages lose room in every part to the user data
+// header that links the parts.
+const (
+	gsm7SingleSeptets = 160
+	gsm7PartSeptets   = 153
+	ucs2SingleUnits   = 70
+	ucs2PartUnits     = 67
+)
+
+// gsm7Basic is the GSM 03.38 default alphabet, one septet per character.
+var gsm7Basic = charset("@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà")
+
+// gsm7Extension characters are sent as an escape plus a septet, so they take
+// two septets and cannot be split across parts.
+var gsm7Extension = charset("\f^{}\\[~]|€")
+
+func charset(chars string) map[rune]bool {
+	set := make(map[rune]bool)
+	for _, r := range chars {
+		set[r] = true
+	}
+	return set
+}
+
+// DetectEncoding returns GSM7 when every character of content is in the GSM
+// alphabet and UCS2 otherwise.
+func DetectEncoding(content string) Encoding {
+	for _, r := range content {
+		if !gsm7Basic[r] && !gsm7Extension[r] {
+			return UCS2
+		}
+	}
+	return GSM7
+}
+
+// Split returns content in the parts it is sent as. Content that fits in one
+// segment is returned as a single part. Characters are never split across
+// parts.
+func Split(content string) (Encoding, []string) {
+	encoding := DetectEncoding(content)
+
+	single, part := gsm7SingleSeptets, gsm7PartSeptets
+	if encoding == UCS2 {
+		single, part = ucs2SingleUnits, ucs2PartUnits
+	}
+
+	if size(content, encoding) <= single {
+		return encoding, []string{content}
+	}
+
+	var parts []string
+	start, used := 0, 0
+	for i, r := range content {
+		n := runeSize(r, encoding)
+		if used+n > part {
+			parts = append(parts, content[start:i])
+			start, used = i, 0
+		}
+		used += n
+	}
+	parts = append(parts, content[start:])
+
+	return encoding, parts
+}
+
+// size is the length of content in septets for GSM7 and UTF-16 code units for
+// UCS2.
+func size(content string, encoding Encoding) int {
+	n := 0
+	for _, r := range content {
+		n += runeSize(r, encoding)
+	}
+	return n
+}
+
+func runeSize(r rune, encoding Encoding) int {
+	if encoding == UCS2 {
+		if utf16.IsSurrogate(r) || r > 0xFFFF {
+			return 2
+		}
+		return 1
+	}
+	if gsm7Extension[r] {
+		return 2
+	}
+	return 1
+}
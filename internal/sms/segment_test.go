@@ -0,0 +1,61 @@
+package sms
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	a := func(n int) string { return strings.Repeat("a", n) }
+
+	tests := []struct {
+		name     string
+		content  string
+		encoding Encoding
+		parts    []string
+	}{
+		{"empty", "", GSM7, []string{""}},
+		{"gsm7 single segment", a(160), GSM7, []string{a(160)}},
+		{"gsm7 one over single segment", a(161), GSM7, []string{a(153), a(8)}},
+		{"gsm7 two full parts", a(306), GSM7, []string{a(153), a(153)}},
+		{"gsm7 one over two parts", a(307), GSM7, []string{a(153), a(153), a(1)}},
+		{"gsm7 extension fills single segment", strings.Repeat("€", 80), GSM7, []string{strings.Repeat("€", 80)}},
+		{"gsm7 extension over single segment", strings.Repeat("€", 81), GSM7, []string{strings.Repeat("€", 76), strings.Repeat("€", 5)}},
+		{"gsm7 extension not split across parts", a(152) + "€" + a(10), GSM7, []string{a(152), "€" + a(10)}},
+		{"ucs2 single segment", a(69) + "ş", UCS2, []string{a(69) + "ş"}},
+		{"ucs2 one over single segment", strings.Repeat("ş", 71), UCS2, []string{strings.Repeat("ş", 67), strings.Repeat("ş", 4)}},
+		{"ucs2 surrogate pairs fill single segment", strings.Repeat("😀", 35), UCS2, []string{strings.Repeat("😀", 35)}},
+		{"ucs2 surrogate pair not split across parts", strings.Repeat("😀", 36), UCS2, []string{strings.Repeat("😀", 33), strings.Repeat("😀", 3)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, parts := Split(tt.content)
+			if encoding != tt.encoding {
+				t.Errorf("encoding = %q, want %q", encoding, tt.encoding)
+			}
+			if !reflect.DeepEqual(parts, tt.parts) {
+				t.Errorf("parts = %q, want %q", parts, tt.parts)
+			}
+		})
+	}
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		content string
+		want    Encoding
+	}{
+		{"Hello, world!", GSM7},
+		{"Teşekkürler", UCS2},
+		{"{price} €5", GSM7},
+		{"Merhaba 👋", UCS2},
+	}
+
+	for _, tt := range tests {
+		if got := DetectEncoding(tt.content); got != tt.want {
+			t.Errorf("DetectEncoding(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package templating
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeLocale canonicalises a BCP 47 style tag, so "tr_tr" and "TR-tr"
+// both become "tr-TR". Only the casing and separators are checked, not whether
+// the language or region exists.
+func NormalizeLocale(locale string) (string, error) {
+	if locale == "" {
+		return "", nil
+	}
+
+	parts := strings.Split(strings.ReplaceAll(locale, "_", "-"), "-")
+	for i, part := range parts {
+		if part == "" || len(part) > 8 || !isAlphanumeric(part) {
+			return "", fmt.Errorf("invalid locale %q", locale)
+		}
+
+		switch {
+		case i == 0:
+			if len(part) < 2 || len(part) > 3 {
+				return "", fmt.Errorf("invalid locale %q", locale)
+			}
+			parts[i] = strings.ToLower(part)
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		default:
+			parts[i] = strings.ToLower(part)
+		}
+	}
+
+	return strings.Join(parts, "-"), nil
+}
+
+// FallbackChain lists the locales to try for locale, most specific first,
+// ending with defaultLocale: "tr-TR" gives ["tr-TR", "tr", "en"].
+func FallbackChain(locale, defaultLocale string) []string {
+	var chain []string
+	seen := map[string]bool{}
+	add := func(tag string) {
+		for tag != "" {
+			if !seen[tag] {
+				seen[tag] = true
+				chain = append(chain, tag)
+			}
+			i := strings.LastIndex(tag, "-")
+			if i < 0 {
+				return
+			}
+			tag = tag[:i]
+		}
+	}
+
+	add(locale)
+	add(defaultLocale)
+	return chain
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
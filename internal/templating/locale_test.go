@@ -0,0 +1,68 @@
+package templating
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		locale  string
+		want    string
+		wantErr bool
+	}{
+		{locale: "", want: ""},
+		{locale: "en", want: "en"},
+		{locale: "EN", want: "en"},
+		{locale: "tr_tr", want: "tr-TR"},
+		{locale: "TR-tr", want: "tr-TR"},
+		{locale: "zh-hant-tw", want: "zh-Hant-TW"},
+		{locale: "es-419", want: "es-419"},
+		{locale: "fil-PH", want: "fil-PH"},
+		{locale: "e", wantErr: true},
+		{locale: "engl", wantErr: true},
+		{locale: "en-", wantErr: true},
+		{locale: "en--US", wantErr: true},
+		{locale: "en-US!", wantErr: true},
+		{locale: "en-toolongtag", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeLocale(tt.locale)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NormalizeLocale(%q) = %q, want error", tt.locale, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NormalizeLocale(%q) returned error: %v", tt.locale, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestFallbackChain(t *testing.T) {
+	tests := []struct {
+		locale        string
+		defaultLocale string
+		want          []string
+	}{
+		{"tr-TR", "en", []string{"tr-TR", "tr", "en"}},
+		{"zh-Hant-TW", "en", []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}},
+		{"en-GB", "en", []string{"en-GB", "en"}},
+		{"en", "en", []string{"en"}},
+		{"", "en", []string{"en"}},
+		{"de", "en-US", []string{"de", "en-US", "en"}},
+		{"", "", nil},
+	}
+
+	for _, tt := range tests {
+		if got := FallbackChain(tt.locale, tt.defaultLocale); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FallbackChain(%q, %q) = %q, want %q", tt.locale, tt.defaultLocale, got, tt.want)
+		}
+	}
+}
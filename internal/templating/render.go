@@ -0,0 +1,47 @@
+package templating
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// ErrRender is wrapped by every error caused by the template or variables
+// themselves, as opposed to failures loading the template.
+var ErrRender = errors.New("template rendering failed")
+
+// Parse checks that body is a valid text/template.
+func Parse(body string) (*template.Template, error) {
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid template: %v", ErrRender, err)
+	}
+	return tmpl, nil
+}
+
+// Render executes body with vars and checks the output fits in maxLength
+// characters. Referencing a variable that is not in vars is an error rather
+// than "<no value>".
+func Render(body string, vars map[string]any, maxLength int) (string, error) {
+	tmpl, err := Parse(body)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, vars); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRender, err)
+	}
+
+	content := sb.String()
+	if content == "" {
+		return "", fmt.Errorf("%w: rendered content is empty", ErrRender)
+	}
+	if n := utf8.RuneCountInString(content); n > maxLength {
+		return "", fmt.Errorf("%w: rendered content is %d characters, exceeds the %d character limit", ErrRender, n, maxLength)
+	}
+
+	return content, nil
+}
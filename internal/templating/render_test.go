@@ -0,0 +1,42 @@
+package templating
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		vars      map[string]any
+		maxLength int
+		want      string
+		wantErr   bool
+	}{
+		{name: "variables", body: "Hi {{.name}}, your code is {{.code}}", vars: map[string]any{"name": "Ayşe", "code": "123456"}, maxLength: 320, want: "Hi Ayşe, your code is 123456"},
+		{name: "at limit", body: "{{.text}}", vars: map[string]any{"text": strings.Repeat("ş", 320)}, maxLength: 320, want: strings.Repeat("ş", 320)},
+		{name: "over limit", body: "{{.text}}", vars: map[string]any{"text": strings.Repeat("a", 321)}, maxLength: 320, wantErr: true},
+		{name: "missing variable", body: "Hi {{.name}}", vars: map[string]any{}, maxLength: 320, wantErr: true},
+		{name: "empty output", body: "{{.name}}", vars: map[string]any{"name": ""}, maxLength: 320, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.body, tt.vars, tt.maxLength)
+			if tt.wantErr {
+				if !errors.Is(err, ErrRender) {
+					t.Fatalf("Render returned %v, want ErrRender", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
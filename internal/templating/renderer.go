@@ -0,0 +1,46 @@
+package templating
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/kubilayrn/ChronoGo/internal/model"
+	"github.com/kubilayrn/ChronoGo/internal/repository"
+)
+
+// Renderer renders stored templates in the best matching locale variant.
+type Renderer struct {
+	templateRepo  *repository.TemplateRepository
+	defaultLocale string
+}
+
+func NewRenderer(templateRepo *repository.TemplateRepository) *Renderer {
+	_ = godotenv.Load()
+
+	defaultLocale, err := NormalizeLocale(os.Getenv("TEMPLATE_DEFAULT_LOCALE"))
+	if err != nil {
+		slog.Warn("Invalid value for TEMPLATE_DEFAULT_LOCALE, using default en")
+		defaultLocale = ""
+	}
+	if defaultLocale == "" {
+		defaultLocale = "en"
+	}
+
+	return &Renderer{
+		templateRepo:  templateRepo,
+		defaultLocale: defaultLocale,
+	}
+}
+
+// Render renders the template of msg for its locale and variables, within the
+// content limit of its channel. Variants are tried along the FallbackChain and
+// the template's own body is used when none matches.
+func (r *Renderer) Render(ctx context.Context, msg model.Message) (string, error) {
+	body, err := r.templateRepo.ResolveBody(ctx, msg.TenantID, *msg.TemplateID, FallbackChain(msg.Locale, r.defaultLocale))
+	if err != nil {
+		return "", err
+	}
+	return Render(body, msg.Variables, msg.Channel.MaxContentLength())
+}
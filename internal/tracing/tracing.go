@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// ServiceName is reported on every span unless OTEL_SERVICE_NAME overrides it.
+const ServiceName = "chronogo"
+
+// InitFromEnv installs W3C trace context and baggage propagation and, when
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set, a
+// global tracer provider exporting spans over OTLP/HTTP. The exporter reads
+// the other standard OTEL_EXPORTER_OTLP_* variables itself.
+//
+// It returns nil when no endpoint is set: spans are then not recorded, but
+// incoming trace context is still passed on to the webhook. Callers shut the
+// provider down on exit to flush pending spans.
+func InitFromEnv(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	_ = godotenv.Load()
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return nil, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	provider, err := NewProvider(ctx, exporter)
+	if err != nil {
+		return nil, err
+	}
+
+	otel.SetTracerProvider(provider)
+	return provider, nil
+}
+
+// NewProvider returns a tracer provider batching spans to exporter, with the
+// service resource set. Sampling follows OTEL_TRACES_SAMPLER, by default every
+// trace that is not already unsampled upstream.
+func NewProvider(ctx context.Context, exporter sdktrace.SpanExporter) (*sdktrace.TracerProvider, error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	), nil
+}
@@ -0,0 +1,184 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a global tracer provider recording every span ended
+// during the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+
+	return recorder
+}
+
+func TestQueryTracer(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		err        error
+		wantName   string
+		wantStatus codes.Code
+	}{
+		{"select", "SELECT id FROM messages WHERE id = $1", nil, "SELECT", codes.Unset},
+		{"leading whitespace", "\n\t\tinsert INTO messages (content) VALUES ($1)", nil, "INSERT", codes.Unset},
+		{"empty", "", nil, "query", codes.Unset},
+		{"no rows is not an error", "SELECT id FROM messages WHERE id = $1", pgx.ErrNoRows, "SELECT", codes.Unset},
+		{"error", "UPDATE messages SET status = $1", errors.New("connection reset"), "UPDATE", codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			tracer := NewQueryTracer()
+
+			ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+				SQL:  tt.sql,
+				Args: []any{"+905551111111"},
+			})
+			tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: tt.err})
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+
+			if span.Name() != tt.wantName {
+				t.Errorf("span name = %q, want %q", span.Name(), tt.wantName)
+			}
+			if span.SpanKind() != trace.SpanKindClient {
+				t.Errorf("span kind = %v, want client", span.SpanKind())
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("span status = %v, want %v", span.Status().Code, tt.wantStatus)
+			}
+
+			attrs := attributes(span.Attributes())
+			if got := attrs[semconv.DBQueryTextKey]; got.AsString() != tt.sql {
+				t.Errorf("%s = %q, want %q", semconv.DBQueryTextKey, got.AsString(), tt.sql)
+			}
+			if got := attrs[semconv.DBSystemNameKey]; got.AsString() != semconv.DBSystemNamePostgreSQL.Value.AsString() {
+				t.Errorf("%s = %q, want postgresql", semconv.DBSystemNameKey, got.AsString())
+			}
+			for _, attr := range span.Attributes() {
+				if attr.Value.Emit() == "+905551111111" {
+					t.Errorf("attribute %s records a query argument", attr.Key)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryTracerParent(t *testing.T) {
+	recorder := recordSpans(t)
+	tracer := NewQueryTracer()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{})
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	query, request := spans[0], spans[1]
+	if query.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Error("query span is not a child of the request span")
+	}
+	if query.SpanContext().TraceID() != request.SpanContext().TraceID() {
+		t.Error("query span is not in the request's trace")
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment.name=test")
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider, err := NewProvider(context.Background(), exporter)
+	if err != nil {
+		t.Fatalf("NewProvider returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	_, span := provider.Tracer("test").Start(context.Background(), "tick")
+	span.End()
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("ForceFlush returned error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+
+	attrs := attributes(spans[0].Resource.Attributes())
+	tests := []struct {
+		key  attribute.Key
+		want string
+	}{
+		{semconv.ServiceNameKey, ServiceName},
+		{"deployment.environment.name", "test"},
+		{semconv.TelemetrySDKNameKey, "opentelemetry"},
+	}
+	for _, tt := range tests {
+		if got := attrs[tt.key].AsString(); got != tt.want {
+			t.Errorf("resource %s = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestInitFromEnvWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	provider, err := InitFromEnv(context.Background())
+	if err != nil {
+		t.Fatalf("InitFromEnv returned error: %v", err)
+	}
+	if provider != nil {
+		t.Error("InitFromEnv returned a provider without an endpoint")
+	}
+
+	// Trace context is still propagated to the webhook.
+	fields := map[string]bool{}
+	for _, field := range otel.GetTextMapPropagator().Fields() {
+		fields[field] = true
+	}
+	for _, field := range []string{"traceparent", "tracestate", "baggage"} {
+		if !fields[field] {
+			t.Errorf("propagator does not carry %s", field)
+		}
+	}
+}
+
+func attributes(kvs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value, len(kvs))
+	for _, kv := range kvs {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS message_attempts (
+    id SERIAL PRIMARY KEY,
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    status_code INTEGER,
+    latency_ms BIGINT NOT NULL,
+    response_body VARCHAR(1024),
+    provider_message_id UUID,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_attempts_message_id ON message_attempts(message_id, started_at);
@@ -0,0 +1,10 @@
+ALTER TABLE messages ALTER COLUMN status TYPE VARCHAR(20);
+
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('unsent', 'sent', 'delivered', 'undelivered', 'read'));
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMP;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS read_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_messages_message_id ON messages(message_id);
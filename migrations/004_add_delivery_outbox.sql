@@ -0,0 +1,13 @@
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('unsent', 'processing', 'sent', 'failed', 'delivered', 'undelivered', 'read'));
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_messages_processing_claimed_at ON messages(claimed_at)
+    WHERE status = 'processing';
+
+-- Attempts are now opened when a message is claimed and closed when it is
+-- finalised, so an attempt row without finished_at is still in flight.
+ALTER TABLE message_attempts ALTER COLUMN finished_at DROP NOT NULL;
+ALTER TABLE message_attempts ALTER COLUMN latency_ms DROP NOT NULL;
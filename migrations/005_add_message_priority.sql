@@ -0,0 +1,6 @@
+-- 0 = low, 1 = normal, 2 = high, 3 = urgent
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 1
+    CHECK (priority BETWEEN 0 AND 3);
+
+CREATE INDEX IF NOT EXISTS idx_messages_unsent_priority ON messages(priority DESC, created_at ASC)
+    WHERE status = 'unsent';
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS templates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_templates_updated_at BEFORE UPDATE ON templates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Templated messages are rendered at send time; content holds the rendered
+-- text once the message has been sent.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS template_id INTEGER REFERENCES templates(id);
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS variables JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_messages_template_id ON messages(template_id);
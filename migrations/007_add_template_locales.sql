@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS template_variants (
+    id SERIAL PRIMARY KEY,
+    template_id INTEGER NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+    locale VARCHAR(35) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (template_id, locale)
+);
+
+CREATE TRIGGER update_template_variants_updated_at BEFORE UPDATE ON template_variants
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS locale VARCHAR(35);
@@ -0,0 +1,6 @@
+-- Recipients are normalised on ingestion: E.164 phone numbers for the webhook
+-- and sms channels, email addresses (up to 254 characters) for email.
+ALTER TABLE messages ALTER COLUMN "to" TYPE VARCHAR(254);
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS channel VARCHAR(20) NOT NULL DEFAULT 'webhook'
+    CHECK (channel IN ('webhook', 'sms', 'email'));
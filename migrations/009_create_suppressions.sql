@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS suppressions (
+    recipient VARCHAR(254) PRIMARY KEY,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('unsent', 'processing', 'sent', 'failed', 'suppressed', 'delivered', 'undelivered', 'read'));
+
+-- Why a message ended in a terminal status other than sent, e.g. the
+-- suppression reason.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS status_reason TEXT;
@@ -0,0 +1,12 @@
+-- IANA time zone of the recipient, used for quiet hours.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS timezone VARCHAR(64);
+
+-- Critical messages are sent during quiet hours.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS critical BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Unsent messages are not picked before send_after (UTC), which is set when a
+-- message is deferred to the end of the recipient's quiet hours.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS send_after TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_messages_unsent_send_after ON messages(send_after)
+    WHERE status = 'unsent';
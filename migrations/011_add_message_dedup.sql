@@ -0,0 +1,22 @@
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('unsent', 'processing', 'sent', 'failed', 'suppressed', 'duplicate', 'delivered', 'undelivered', 'read'));
+
+-- Client supplied key or a hash of the content, see internal/dedup.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS dedup_key VARCHAR(128);
+
+CREATE INDEX IF NOT EXISTS idx_messages_dedup ON messages("to", dedup_key, sent_at)
+    WHERE dedup_key IS NOT NULL;
+
+-- One row per recipient and key while the deduplication window is open. The
+-- primary key is the source of truth for ingestion deduplication; expired rows
+-- are taken over by the next message with the same key.
+CREATE TABLE IF NOT EXISTS message_dedup_keys (
+    recipient VARCHAR(254) NOT NULL,
+    dedup_key VARCHAR(128) NOT NULL,
+    message_id INTEGER REFERENCES messages(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (recipient, dedup_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_dedup_keys_expires_at ON message_dedup_keys(expires_at);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS campaigns (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    template_id INTEGER NOT NULL REFERENCES templates(id),
+    channel VARCHAR(20) NOT NULL DEFAULT 'webhook',
+    priority SMALLINT NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'paused', 'cancelled')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_campaigns_updated_at BEFORE UPDATE ON campaigns
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS campaign_id INTEGER REFERENCES campaigns(id);
+
+CREATE INDEX IF NOT EXISTS idx_messages_campaign_id ON messages(campaign_id, status);
+
+-- Unsent messages of a cancelled campaign are cancelled.
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('unsent', 'processing', 'sent', 'failed', 'suppressed', 'duplicate', 'cancelled', 'delivered', 'undelivered', 'read'));
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS contacts (
+    id SERIAL PRIMARY KEY,
+    recipient VARCHAR(254) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    locale VARCHAR(35),
+    timezone VARCHAR(64),
+    attributes JSONB NOT NULL DEFAULT '{}',
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_contacts_updated_at BEFORE UPDATE ON contacts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Segments are tag queries, see ContactRepository.FindContacts.
+CREATE INDEX IF NOT EXISTS idx_contacts_tags ON contacts USING GIN (tags);
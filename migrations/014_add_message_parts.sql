@@ -0,0 +1,22 @@
+-- Long content is split into SMS segments when it is sent, see internal/sms.
+ALTER TABLE messages ALTER COLUMN content TYPE VARCHAR(1600);
+
+CREATE TABLE IF NOT EXISTS message_parts (
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    number SMALLINT NOT NULL,
+    total SMALLINT NOT NULL,
+    content VARCHAR(1600) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed')),
+    provider_message_id UUID,
+    error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (message_id, number)
+);
+
+CREATE TRIGGER update_message_parts_updated_at BEFORE UPDATE ON message_parts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Delivery receipts reference the provider id of a part.
+CREATE INDEX IF NOT EXISTS idx_message_parts_provider_message_id ON message_parts(provider_message_id);
@@ -0,0 +1,5 @@
+-- Caller that created the message: "admin", "api-key:<id>" or "jwt:<sub>".
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS created_by VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_messages_created_by ON messages(created_by)
+    WHERE created_by IS NOT NULL;
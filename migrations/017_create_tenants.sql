@@ -0,0 +1,32 @@
+-- Tenants share the deployment but only see their own messages, campaigns and
+-- API keys. Rows created before tenants existed belong to 'default'.
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    -- NULL uses WEBHOOK_URL and WEBHOOK_AUTH_KEY.
+    webhook_url TEXT,
+    webhook_auth_key TEXT,
+    -- Most messages of the tenant claimed per scheduler batch; NULL is no limit
+    -- beyond SCHEDULER_MESSAGE_LIMIT.
+    batch_limit INTEGER CHECK (batch_limit > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_tenants_updated_at BEFORE UPDATE ON tenants
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO tenants (id, name) VALUES ('default', 'Default') ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants(id);
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants(id);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants(id);
+
+CREATE INDEX IF NOT EXISTS idx_messages_tenant_id ON messages(tenant_id, status);
+CREATE INDEX IF NOT EXISTS idx_campaigns_tenant_id ON campaigns(tenant_id);
+
+-- Deduplication is per tenant: two tenants may send the same notification to
+-- the same recipient.
+ALTER TABLE message_dedup_keys ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE message_dedup_keys DROP CONSTRAINT IF EXISTS message_dedup_keys_pkey;
+ALTER TABLE message_dedup_keys ADD PRIMARY KEY (tenant_id, recipient, dedup_key);
@@ -0,0 +1,9 @@
+-- Content may be encrypted at rest, see internal/encryption. Encrypted content
+-- is longer than the plain text, so the columns drop their length limit; the
+-- API still caps content at 1600 characters.
+ALTER TABLE messages ALTER COLUMN content TYPE TEXT;
+ALTER TABLE message_parts ALTER COLUMN content TYPE TEXT;
+
+-- ID of the key the content is encrypted with; NULL for plain text.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS content_key_id VARCHAR(64);
+ALTER TABLE message_parts ADD COLUMN IF NOT EXISTS content_key_id VARCHAR(64);
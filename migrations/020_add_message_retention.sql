@@ -0,0 +1,25 @@
+-- Messages past their retention period are moved here, see internal/retention.
+-- The archive has no foreign keys, so archived messages outlive their
+-- campaigns, templates and tenants. Columns added to messages must be added
+-- here too, and to archivedMessageColumns.
+CREATE TABLE IF NOT EXISTS messages_archive (
+    LIKE messages,
+    archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_archive_tenant_id ON messages_archive(tenant_id, created_at);
+
+-- Retention picks the oldest messages of one status at a time.
+CREATE INDEX IF NOT EXISTS idx_messages_status_created_at ON messages(status, created_at);
+
+-- One row per retention pass, with the number of messages purged per status.
+CREATE TABLE IF NOT EXISTS retention_runs (
+    id BIGSERIAL PRIMARY KEY,
+    mode VARCHAR(10) NOT NULL CHECK (mode IN ('archive', 'delete')),
+    purged JSONB NOT NULL DEFAULT '{}',
+    total INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,15 @@
+-- Contacts, templates and suppressions belong to a tenant like messages do.
+-- Rows created before this migration belong to 'default'. Recipients and
+-- template names are unique per tenant, so two tenants can each have a
+-- contact for, or a suppression of, the same recipient.
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants(id);
+ALTER TABLE contacts DROP CONSTRAINT IF EXISTS contacts_recipient_key;
+ALTER TABLE contacts ADD CONSTRAINT contacts_tenant_id_recipient_key UNIQUE (tenant_id, recipient);
+
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants(id);
+ALTER TABLE templates DROP CONSTRAINT IF EXISTS templates_name_key;
+ALTER TABLE templates ADD CONSTRAINT templates_tenant_id_name_key UNIQUE (tenant_id, name);
+
+ALTER TABLE suppressions ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants(id);
+ALTER TABLE suppressions DROP CONSTRAINT IF EXISTS suppressions_pkey;
+ALTER TABLE suppressions ADD PRIMARY KEY (tenant_id, recipient);
@@ -0,0 +1,5 @@
+-- Requests per rate limit window. rate_limit is shared by all of the tenant's
+-- callers and rate_limit_per_key applies to each of them. NULL uses
+-- RATE_LIMIT_PER_TENANT and RATE_LIMIT_PER_KEY; 0 disables the limit.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS rate_limit INTEGER CHECK (rate_limit >= 0);
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS rate_limit_per_key INTEGER CHECK (rate_limit_per_key >= 0);
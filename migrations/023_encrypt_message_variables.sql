@@ -0,0 +1,8 @@
+-- Variables are encrypted at rest like content, see 019. Encrypted variables
+-- are not JSON, so the column holds the JSON document as text.
+ALTER TABLE messages ALTER COLUMN variables TYPE TEXT USING variables::text;
+ALTER TABLE messages_archive ALTER COLUMN variables TYPE TEXT USING variables::text;
+
+-- ID of the key the variables are encrypted with; NULL for plain JSON.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS variables_key_id VARCHAR(64);
+ALTER TABLE messages_archive ADD COLUMN IF NOT EXISTS variables_key_id VARCHAR(64);
@@ -0,0 +1,15 @@
+-- Archiving a message also moves its delivery attempts and parts, see
+-- internal/retention. Like messages_archive these tables have no foreign keys.
+-- Columns added to message_attempts or message_parts must be added here too,
+-- and to archivedAttemptColumns or archivedPartColumns.
+CREATE TABLE IF NOT EXISTS message_attempts_archive (
+    LIKE message_attempts,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_attempts_archive_message_id ON message_attempts_archive(message_id, started_at);
+
+CREATE TABLE IF NOT EXISTS message_parts_archive (
+    LIKE message_parts,
+    PRIMARY KEY (message_id, number)
+);